@@ -20,6 +20,7 @@ import (
 	"github.com/supabase/cli/internal/db/remote/changes"
 	"github.com/supabase/cli/internal/db/remote/commit"
 	"github.com/supabase/cli/internal/db/reset"
+	"github.com/supabase/cli/internal/db/restore"
 	"github.com/supabase/cli/internal/db/start"
 	"github.com/supabase/cli/internal/db/test"
 	"github.com/supabase/cli/internal/utils"
@@ -84,16 +85,27 @@ var (
 		},
 	}
 
-	useMigra    bool
-	usePgAdmin  bool
-	usePgSchema bool
-	schema      []string
-	file        string
+	useMigra       bool
+	usePgAdmin     bool
+	usePgSchema    bool
+	includeManaged bool
+	diffData       bool
+	dataTable      []string
+	schema         []string
+	file           string
 
 	dbDiffCmd = &cobra.Command{
 		Use:   "diff",
 		Short: "Diffs the local database for schema changes",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if diffData {
+				cobra.CheckErr(cmd.MarkFlagRequired("table"))
+			}
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if diffData {
+				return diff.RunData(cmd.Context(), dataTable, file, flags.DbConfig, afero.NewOsFs())
+			}
 			if usePgAdmin {
 				return diff.RunPgAdmin(cmd.Context(), schema, file, flags.DbConfig, afero.NewOsFs())
 			}
@@ -102,6 +114,9 @@ var (
 				differ = diff.DiffPgSchema
 				fmt.Fprintln(os.Stderr, "WARNING: --use-pg-schema flag is experimental and may not include all entities, such as RLS policies, enums, and grants.")
 			}
+			if includeManaged {
+				differ = diff.WithManagedObjects(differ)
+			}
 			return diff.Run(cmd.Context(), schema, file, flags.DbConfig, differ, afero.NewOsFs())
 		},
 	}
@@ -110,18 +125,21 @@ var (
 	useCopy      bool
 	roleOnly     bool
 	keepComments bool
+	anonymize    bool
 	excludeTable []string
+	subset       string
+	splitDir     string
 
 	dbDumpCmd = &cobra.Command{
 		Use:   "dump",
 		Short: "Dumps data or schemas from the remote database",
 		PreRun: func(cmd *cobra.Command, args []string) {
-			if useCopy || len(excludeTable) > 0 {
+			if useCopy || anonymize || len(excludeTable) > 0 || len(subset) > 0 {
 				cobra.CheckErr(cmd.MarkFlagRequired("data-only"))
 			}
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return dump.Run(cmd.Context(), file, flags.DbConfig, schema, excludeTable, dataOnly, roleOnly, keepComments, useCopy, dryRun, afero.NewOsFs())
+			return dump.Run(cmd.Context(), file, flags.DbConfig, schema, excludeTable, subset, splitDir, dataOnly, roleOnly, keepComments, useCopy, anonymize, dryRun, afero.NewOsFs())
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
 			if len(file) > 0 {
@@ -151,7 +169,7 @@ var (
 			if len(args) > 0 {
 				name = args[0]
 			}
-			return pull.Run(cmd.Context(), schema, flags.DbConfig, name, afero.NewOsFs())
+			return pull.Run(cmd.Context(), schema, flags.DbConfig, name, includeManaged, afero.NewOsFs())
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
 			fmt.Println("Finished " + utils.Aqua("supabase db pull") + ".")
@@ -183,24 +201,53 @@ var (
 		},
 	}
 
+	watch bool
+
 	dbResetCmd = &cobra.Command{
 		Use:   "reset",
 		Short: "Resets the local database to current migrations",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if watch {
+				return reset.Watch(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			}
 			return reset.Run(cmd.Context(), migrationVersion, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
+	cleanRestore bool
+	restoreJobs  uint
+
+	dbRestoreCmd = &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Restores a dump file to the local or remote database",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return restore.Run(cmd.Context(), args[0], flags.DbConfig, schema, dataOnly, cleanRestore, restoreJobs, afero.NewOsFs())
+		},
+	}
+
 	level = utils.EnumFlag{
 		Allowed: lint.AllowedLevels,
 		Value:   lint.AllowedLevels[0],
 	}
 
+	lintOutput = utils.EnumFlag{
+		Allowed: lint.AllowedOutputs,
+		Value:   lint.AllowedOutputs[0],
+	}
+
+	failOn = utils.EnumFlag{
+		Allowed: lint.AllowedLevels,
+	}
+
+	lintRules        []string
+	lintExcludeRules []string
+
 	dbLintCmd = &cobra.Command{
 		Use:   "lint",
 		Short: "Checks local database for typing error",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return lint.Run(cmd.Context(), schema, level.Value, flags.DbConfig, afero.NewOsFs())
+			return lint.Run(cmd.Context(), schema, lintRules, lintExcludeRules, level.Value, failOn.Value, lintOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -217,7 +264,10 @@ var (
 		Use:    "test [path] ...",
 		Short:  "Tests local database with pgTAP",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return test.Run(cmd.Context(), args, flags.DbConfig, afero.NewOsFs())
+			if watch {
+				return test.Watch(cmd.Context(), args, testJobs, flags.DbConfig, afero.NewOsFs())
+			}
+			return test.Run(cmd.Context(), args, testOutput.Value, testOutputFile, testJobs, testCoverage, testCoverageOutput.Value, testCoverageFile, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 )
@@ -235,12 +285,21 @@ func init() {
 	diffFlags.BoolVar(&usePgAdmin, "use-pgadmin", false, "Use pgAdmin to generate schema diff.")
 	diffFlags.BoolVar(&usePgSchema, "use-pg-schema", false, "Use pg-schema-diff to generate schema diff.")
 	dbDiffCmd.MarkFlagsMutuallyExclusive("use-migra", "use-pgadmin")
+	diffFlags.BoolVar(&includeManaged, "include-managed", false, "Include user owned policies, triggers, grants and functions in managed schemas.")
+	dbDiffCmd.MarkFlagsMutuallyExclusive("include-managed", "use-pgadmin")
+	diffFlags.BoolVar(&diffData, "data", false, "Diffs table rows by primary key instead of schema.")
+	diffFlags.StringSliceVarP(&dataTable, "table", "t", []string{}, "Comma separated list of schema.tables to diff data.")
+	dbDiffCmd.MarkFlagsMutuallyExclusive("data", "include-managed")
+	dbDiffCmd.MarkFlagsMutuallyExclusive("data", "use-migra")
+	dbDiffCmd.MarkFlagsMutuallyExclusive("data", "use-pgadmin")
+	dbDiffCmd.MarkFlagsMutuallyExclusive("data", "use-pg-schema")
 	diffFlags.String("db-url", "", "Diffs against the database specified by the connection string (must be percent-encoded).")
 	diffFlags.Bool("linked", false, "Diffs local migration files against the linked project.")
 	diffFlags.Bool("local", true, "Diffs local migration files against the local database.")
 	dbDiffCmd.MarkFlagsMutuallyExclusive("db-url", "linked", "local")
 	diffFlags.StringVarP(&file, "file", "f", "", "Saves schema diff to a new migration file.")
 	diffFlags.StringSliceVarP(&schema, "schema", "s", []string{}, "Comma separated list of schema to include.")
+	dbDiffCmd.MarkFlagsMutuallyExclusive("data", "schema")
 	dbCmd.AddCommand(dbDiffCmd)
 	// Build dump command
 	dumpFlags := dbDumpCmd.Flags()
@@ -248,11 +307,20 @@ func init() {
 	dumpFlags.BoolVar(&dataOnly, "data-only", false, "Dumps only data records.")
 	dumpFlags.BoolVar(&useCopy, "use-copy", false, "Uses copy statements in place of inserts.")
 	dumpFlags.StringSliceVarP(&excludeTable, "exclude", "x", []string{}, "List of schema.tables to exclude from data-only dump.")
+	dumpFlags.BoolVar(&anonymize, "anonymize", false, "Masks personal information in auth.users when dumping data.")
+	dumpFlags.StringVar(&subset, "subset", "", "Dumps only rows matching the root query and their related rows, ie. \"public.orgs where id in (1, 2)\".")
+	dbDumpCmd.MarkFlagsMutuallyExclusive("subset", "use-copy")
+	dbDumpCmd.MarkFlagsMutuallyExclusive("subset", "dry-run")
 	dumpFlags.BoolVar(&roleOnly, "role-only", false, "Dumps only cluster roles.")
 	dbDumpCmd.MarkFlagsMutuallyExclusive("role-only", "data-only")
 	dumpFlags.BoolVar(&keepComments, "keep-comments", false, "Keeps commented lines from pg_dump output.")
 	dbDumpCmd.MarkFlagsMutuallyExclusive("keep-comments", "data-only")
 	dumpFlags.StringVarP(&file, "file", "f", "", "File path to save the dumped contents.")
+	dumpFlags.StringVar(&splitDir, "split-dir", "", "Directory to save the dumped schema as one file per object.")
+	dbDumpCmd.MarkFlagsMutuallyExclusive("split-dir", "file")
+	dbDumpCmd.MarkFlagsMutuallyExclusive("split-dir", "data-only")
+	dbDumpCmd.MarkFlagsMutuallyExclusive("split-dir", "role-only")
+	dbDumpCmd.MarkFlagsMutuallyExclusive("split-dir", "dry-run")
 	dumpFlags.String("db-url", "", "Dumps from the database specified by the connection string (must be percent-encoded).")
 	dumpFlags.Bool("linked", true, "Dumps from the linked project.")
 	dumpFlags.Bool("local", false, "Dumps from the local database.")
@@ -261,6 +329,7 @@ func init() {
 	cobra.CheckErr(viper.BindPFlag("DB_PASSWORD", dumpFlags.Lookup("password")))
 	dumpFlags.StringSliceVarP(&schema, "schema", "s", []string{}, "Comma separated list of schema to include.")
 	dbDumpCmd.MarkFlagsMutuallyExclusive("schema", "role-only")
+	dbDumpCmd.MarkFlagsMutuallyExclusive("schema", "subset")
 	dbCmd.AddCommand(dbDumpCmd)
 	// Build push command
 	pushFlags := dbPushCmd.Flags()
@@ -278,6 +347,7 @@ func init() {
 	// Build pull command
 	pullFlags := dbPullCmd.Flags()
 	pullFlags.StringSliceVarP(&schema, "schema", "s", []string{}, "Comma separated list of schema to include.")
+	pullFlags.BoolVar(&includeManaged, "include-managed", false, "Include user owned policies, triggers, grants and functions in managed schemas.")
 	pullFlags.String("db-url", "", "Pulls from the database specified by the connection string (must be percent-encoded).")
 	pullFlags.Bool("linked", true, "Pulls from the linked project.")
 	pullFlags.Bool("local", false, "Pulls from the local database.")
@@ -301,7 +371,22 @@ func init() {
 	resetFlags.Bool("local", true, "Resets the local database with local migrations.")
 	dbResetCmd.MarkFlagsMutuallyExclusive("db-url", "linked", "local")
 	resetFlags.StringVar(&migrationVersion, "version", "", "Reset up to the specified version.")
+	resetFlags.BoolVar(&watch, "watch", false, "Watch for changes to migrations and seed file.")
+	dbResetCmd.MarkFlagsMutuallyExclusive("watch", "version")
 	dbCmd.AddCommand(dbResetCmd)
+	// Build restore command
+	restoreFlags := dbRestoreCmd.Flags()
+	restoreFlags.BoolVar(&dataOnly, "data-only", false, "Restores only data records from the archive.")
+	restoreFlags.StringSliceVarP(&schema, "schema", "s", []string{}, "Comma separated list of schema to restore from the archive.")
+	restoreFlags.BoolVar(&cleanRestore, "clean", false, "Drops database objects before recreating them.")
+	restoreFlags.UintVarP(&restoreJobs, "jobs", "j", 1, "Number of parallel jobs to restore the archive.")
+	restoreFlags.String("db-url", "", "Restores to the database specified by the connection string (must be percent-encoded).")
+	restoreFlags.Bool("linked", false, "Restores to the linked project.")
+	restoreFlags.Bool("local", true, "Restores to the local database.")
+	dbRestoreCmd.MarkFlagsMutuallyExclusive("db-url", "linked", "local")
+	restoreFlags.StringVarP(&dbPassword, "password", "p", "", "Password to your remote Postgres database.")
+	cobra.CheckErr(viper.BindPFlag("DB_PASSWORD", restoreFlags.Lookup("password")))
+	dbCmd.AddCommand(dbRestoreCmd)
 	// Build lint command
 	lintFlags := dbLintCmd.Flags()
 	lintFlags.String("db-url", "", "Lints the database specified by the connection string (must be percent-encoded).")
@@ -310,6 +395,10 @@ func init() {
 	dbLintCmd.MarkFlagsMutuallyExclusive("db-url", "linked", "local")
 	lintFlags.StringSliceVarP(&schema, "schema", "s", []string{}, "Comma separated list of schema to include.")
 	lintFlags.Var(&level, "level", "Error level to emit.")
+	lintFlags.VarP(&lintOutput, "output", "o", "Output format of lint results.")
+	lintFlags.Var(&failOn, "fail-on", "Error level to exit with non-zero status.")
+	lintFlags.StringSliceVar(&lintRules, "rule", []string{}, "Comma separated list of advisor rules to check, or all.")
+	lintFlags.StringSliceVar(&lintExcludeRules, "exclude-rule", []string{}, "Comma separated list of advisor rules to skip when checking all.")
 	dbCmd.AddCommand(dbLintCmd)
 	// Build start command
 	dbCmd.AddCommand(dbStartCmd)
@@ -320,5 +409,14 @@ func init() {
 	testFlags.Bool("linked", false, "Runs pgTAP tests on the linked project.")
 	testFlags.Bool("local", true, "Runs pgTAP tests on the local database.")
 	dbTestCmd.MarkFlagsMutuallyExclusive("db-url", "linked", "local")
+	testFlags.Var(&testOutput, "output", "Output format of test results.")
+	testFlags.StringVar(&testOutputFile, "output-file", "", "File path to save the test report.")
+	testFlags.UintVarP(&testJobs, "jobs", "j", 1, "Number of database copies to run tests in parallel.")
+	testFlags.BoolVar(&testCoverage, "coverage", false, "Report line coverage of PL/pgSQL functions and policies on unreferenced tables.")
+	testFlags.Var(&testCoverageOutput, "coverage-output", "Output format of coverage report.")
+	testFlags.StringVar(&testCoverageFile, "coverage-file", "", "File path to save the coverage report.")
+	testFlags.BoolVar(&watch, "watch", false, "Re-run tests on changes to test files, migrations and seed file.")
+	dbTestCmd.MarkFlagsMutuallyExclusive("watch", "coverage")
+	dbTestCmd.MarkFlagsMutuallyExclusive("watch", "output-file")
 	rootCmd.AddCommand(dbCmd)
 }
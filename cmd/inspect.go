@@ -1,25 +1,42 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"time"
 
+	"github.com/go-errors/errors"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/supabase/cli/internal/inspect"
 	"github.com/supabase/cli/internal/inspect/bloat"
 	"github.com/supabase/cli/internal/inspect/blocking"
 	"github.com/supabase/cli/internal/inspect/cache"
+	"github.com/supabase/cli/internal/inspect/check"
+	"github.com/supabase/cli/internal/inspect/custom"
+	"github.com/supabase/cli/internal/utils"
 	"github.com/supabase/cli/internal/utils/flags"
 
 	"github.com/supabase/cli/internal/inspect/calls"
+	"github.com/supabase/cli/internal/inspect/explain"
+	"github.com/supabase/cli/internal/inspect/growth"
+	"github.com/supabase/cli/internal/inspect/index_advisor"
 	"github.com/supabase/cli/internal/inspect/index_sizes"
 	"github.com/supabase/cli/internal/inspect/index_usage"
 	"github.com/supabase/cli/internal/inspect/locks"
 	"github.com/supabase/cli/internal/inspect/long_running_queries"
 	"github.com/supabase/cli/internal/inspect/outliers"
+	"github.com/supabase/cli/internal/inspect/privileges"
 	"github.com/supabase/cli/internal/inspect/replication_slots"
+	"github.com/supabase/cli/internal/inspect/report"
 	"github.com/supabase/cli/internal/inspect/role_connections"
 	"github.com/supabase/cli/internal/inspect/seq_scans"
+	"github.com/supabase/cli/internal/inspect/sessions"
+	"github.com/supabase/cli/internal/inspect/snapshot"
+	"github.com/supabase/cli/internal/inspect/statements"
 	"github.com/supabase/cli/internal/inspect/table_index_sizes"
 	"github.com/supabase/cli/internal/inspect/table_record_counts"
 	"github.com/supabase/cli/internal/inspect/table_sizes"
@@ -30,6 +47,28 @@ import (
 )
 
 var (
+	inspectOutput = utils.EnumFlag{
+		Allowed: inspect.AllowedOutputs,
+		Value:   utils.OutputPretty,
+	}
+
+	reportFormat = utils.EnumFlag{
+		Allowed: report.AllowedFormats,
+		Value:   report.FormatMarkdown,
+	}
+	reportOutputFile   string
+	checkRules         string
+	sampleInterval     time.Duration
+	sampleWatch        bool
+	sessionInteractive bool
+	sessionYes         bool
+	cancelOlderThan    time.Duration
+	terminate          bool
+	explainAnalyze     bool
+	explainRole        string
+	explainClaims      string
+	growthTarget       string
+
 	inspectCmd = &cobra.Command{
 		GroupID: groupLocalDev,
 		Use:     "inspect",
@@ -39,18 +78,31 @@ var (
 	inspectDBCmd = &cobra.Command{
 		Use:   "db",
 		Short: "Tools to inspect your Supabase database",
+		// Arguments that are not built-in commands run user defined queries
+		Args:              validateCustomInspectCmd,
+		ValidArgsFunction: completeCustomInspectCmds,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Help is printed without connecting to the database
+			if cmd.HasSubCommands() && len(args) == 0 {
+				return nil
+			}
 			ctx, _ := signal.NotifyContext(cmd.Context(), os.Interrupt)
 			cmd.SetContext(ctx)
 			return cmd.Root().PersistentPreRunE(cmd, args)
 		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return cmd.Help()
+			}
+			return custom.Run(cmd.Context(), inspectOutput.Value, args[0], flags.DbConfig, afero.NewOsFs())
+		},
 	}
 
 	inspectCacheHitCmd = &cobra.Command{
 		Use:   "cache-hit",
 		Short: "Show cache hit rates for tables and indices",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return cache.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return cache.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -58,7 +110,7 @@ var (
 		Use:   "replication-slots",
 		Short: "Show information about replication slots on the database",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return replication_slots.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return replication_slots.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -66,7 +118,7 @@ var (
 		Use:   "index-usage",
 		Short: "Show information about the efficiency of indexes",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return index_usage.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return index_usage.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -74,7 +126,7 @@ var (
 		Use:   "locks",
 		Short: "Show queries which have taken out an exclusive lock on a relation",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return locks.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return locks.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -82,23 +134,40 @@ var (
 		Use:   "blocking",
 		Short: "Show queries that are holding locks and the queries that are waiting for them to be released",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return blocking.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			if cancelOlderThan > 0 {
+				return sessions.Run(cmd.Context(), inspectOutput.Value, sessions.ListBlocking, cancelOlderThan, terminate, dryRun, sessionYes, flags.DbConfig, afero.NewOsFs())
+			} else if sessionInteractive {
+				return sessions.RunInteractive(cmd.Context(), sessions.ListBlocking, 0, flags.DbConfig, afero.NewOsFs())
+			}
+			return blocking.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
 	inspectOutliersCmd = &cobra.Command{
-		Use:   "outliers",
-		Short: "Show queries from pg_stat_statements ordered by total execution time",
+		Use:     "outliers",
+		Short:   "Show queries from pg_stat_statements ordered by total execution time",
+		PreRunE: validateSampleFlags,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return outliers.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			if sampleWatch {
+				return statements.Watch(cmd.Context(), statements.OrderByTotalTime, sampleInterval, flags.DbConfig)
+			} else if sampleInterval > 0 {
+				return statements.Run(cmd.Context(), inspectOutput.Value, statements.OrderByTotalTime, sampleInterval, flags.DbConfig)
+			}
+			return outliers.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
 	inspectCallsCmd = &cobra.Command{
-		Use:   "calls",
-		Short: "Show queries from pg_stat_statements ordered by total times called",
+		Use:     "calls",
+		Short:   "Show queries from pg_stat_statements ordered by total times called",
+		PreRunE: validateSampleFlags,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return calls.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			if sampleWatch {
+				return statements.Watch(cmd.Context(), statements.OrderByCalls, sampleInterval, flags.DbConfig)
+			} else if sampleInterval > 0 {
+				return statements.Run(cmd.Context(), inspectOutput.Value, statements.OrderByCalls, sampleInterval, flags.DbConfig)
+			}
+			return calls.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -106,7 +175,7 @@ var (
 		Use:   "total-index-size",
 		Short: "Show total size of all indexes",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return total_index_size.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return total_index_size.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -114,7 +183,7 @@ var (
 		Use:   "index-sizes",
 		Short: "Show index sizes of individual indexes",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return index_sizes.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return index_sizes.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -122,7 +191,7 @@ var (
 		Use:   "table-sizes",
 		Short: "Show table sizes of individual tables without their index sizes",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return table_sizes.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return table_sizes.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -130,7 +199,7 @@ var (
 		Use:   "table-index-sizes",
 		Short: "Show index sizes of individual tables",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return table_index_sizes.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return table_index_sizes.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -138,7 +207,7 @@ var (
 		Use:   "total-table-sizes",
 		Short: "Show total table sizes, including table index sizes",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return total_table_sizes.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return total_table_sizes.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -146,7 +215,7 @@ var (
 		Use:   "unused-indexes",
 		Short: "Show indexes with low usage",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return unused_indexes.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return unused_indexes.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -154,7 +223,7 @@ var (
 		Use:   "seq-scans",
 		Short: "Show number of sequential scans recorded against all tables",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return seq_scans.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return seq_scans.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -162,7 +231,12 @@ var (
 		Use:   "long-running-queries",
 		Short: "Show currently running queries running for longer than 5 minutes",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return long_running_queries.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			if cancelOlderThan > 0 {
+				return sessions.Run(cmd.Context(), inspectOutput.Value, sessions.ListLongRunning, cancelOlderThan, terminate, dryRun, sessionYes, flags.DbConfig, afero.NewOsFs())
+			} else if sessionInteractive {
+				return sessions.RunInteractive(cmd.Context(), sessions.ListLongRunning, sessions.DefaultLongRunning, flags.DbConfig, afero.NewOsFs())
+			}
+			return long_running_queries.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -170,7 +244,7 @@ var (
 		Use:   "table-record-counts",
 		Short: "Show estimated number of rows per table",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return table_record_counts.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return table_record_counts.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -178,7 +252,7 @@ var (
 		Use:   "bloat",
 		Short: "Estimates space allocated to a relation that is full of dead tuples",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return bloat.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return bloat.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -186,7 +260,7 @@ var (
 		Use:   "vacuum-stats",
 		Short: "Show statistics related to vacuum operations per table",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return vacuum_stats.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return vacuum_stats.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 
@@ -194,25 +268,109 @@ var (
 		Use:   "role-connections",
 		Short: "Show number of active connections for all database roles",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return role_connections.Run(cmd.Context(), flags.DbConfig, afero.NewOsFs())
+			return role_connections.Run(cmd.Context(), inspectOutput.Value, flags.DbConfig, afero.NewOsFs())
+		},
+	}
+
+	inspectIndexAdvisorCmd = &cobra.Command{
+		Use:   "index-advisor",
+		Short: "Suggest indexes for the slowest queries using hypothetical indexes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return index_advisor.Run(cmd.Context(), inspectOutput.Value, file, flags.DbConfig, afero.NewOsFs())
+		},
+	}
+
+	inspectExplainCmd = &cobra.Command{
+		Use:   "explain",
+		Short: "Explain a query with the privileges of a database role",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return explain.Run(cmd.Context(), inspectOutput.Value, file, explainAnalyze, explainRole, explainClaims, flags.DbConfig, afero.NewOsFs())
+		},
+	}
+
+	inspectSnapshotCmd = &cobra.Command{
+		Use:   "snapshot",
+		Short: "Save table sizes, row counts and bloat to a local history file",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return snapshot.Run(cmd.Context(), flags.ProjectRef, flags.DbConfig, afero.NewOsFs())
+		},
+	}
+
+	inspectGrowthCmd = &cobra.Command{
+		Use:   "growth",
+		Short: "Show growth rates of tables from saved snapshots",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, err := inspect.ParseSize(growthTarget)
+			if err != nil {
+				return err
+			}
+			return growth.Run(cmd.Context(), inspectOutput.Value, target, flags.ProjectRef, flags.DbConfig, afero.NewOsFs())
+		},
+	}
+
+	inspectPrivilegesCmd = &cobra.Command{
+		Use:   "privileges",
+		Short: "Show privileges of API roles and RLS policies on exposed objects",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return privileges.Run(cmd.Context(), inspectOutput.Value, schema, flags.DbConfig, afero.NewOsFs())
+		},
+	}
+
+	inspectCheckCmd = &cobra.Command{
+		Use:   "check",
+		Short: "Check inspection results against thresholds",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return check.Run(cmd.Context(), inspectOutput.Value, checkRules, flags.DbConfig, afero.NewOsFs())
+		},
+	}
+
+	inspectReportCmd = &cobra.Command{
+		Use:   "report",
+		Short: "Generate a health report from all inspections",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return report.Run(cmd.Context(), reportFormat.Value, reportOutputFile, flags.DbConfig, afero.NewOsFs())
 		},
 	}
 )
 
+func validateSampleFlags(cmd *cobra.Command, args []string) error {
+	if sampleInterval < 0 {
+		return errors.Errorf("Invalid interval: %s (must be positive)", sampleInterval)
+	}
+	if sampleWatch && cmd.Flags().Changed("output") {
+		return errors.New("--watch cannot be used with --output")
+	}
+	return nil
+}
+
 func init() {
 	inspectFlags := inspectDBCmd.PersistentFlags()
 	inspectFlags.String("db-url", "", "Inspect the database specified by the connection string (must be percent-encoded).")
 	inspectFlags.Bool("linked", true, "Inspect the linked project.")
 	inspectFlags.Bool("local", false, "Inspect the local database.")
 	inspectDBCmd.MarkFlagsMutuallyExclusive("db-url", "linked", "local")
+	inspectFlags.VarP(&inspectOutput, "output", "o", "Output format of inspect results.")
 	inspectCmd.AddCommand(inspectDBCmd)
 	inspectDBCmd.AddCommand(inspectCacheHitCmd)
 	inspectDBCmd.AddCommand(inspectReplicationSlotsCmd)
 	inspectDBCmd.AddCommand(inspectIndexUsageCmd)
 	inspectDBCmd.AddCommand(inspectLocksCmd)
+	for _, cmd := range []*cobra.Command{inspectBlockingCmd, inspectLongRunningQueriesCmd} {
+		sessionFlags := cmd.Flags()
+		sessionFlags.BoolVarP(&sessionInteractive, "interactive", "i", false, "Select sessions to cancel or terminate interactively.")
+		sessionFlags.DurationVar(&cancelOlderThan, "cancel-older-than", 0, "Cancel all queries running for longer than this duration.")
+		sessionFlags.BoolVar(&terminate, "terminate", false, "Terminate sessions instead of cancelling their queries.")
+		sessionFlags.BoolVar(&dryRun, "dry-run", false, "Print the sessions that would be cancelled without cancelling them.")
+		sessionFlags.BoolVar(&sessionYes, "yes", false, "Cancel or terminate sessions without asking for confirmation.")
+		cmd.MarkFlagsMutuallyExclusive("interactive", "cancel-older-than")
+	}
 	inspectDBCmd.AddCommand(inspectBlockingCmd)
-	inspectDBCmd.AddCommand(inspectOutliersCmd)
-	inspectDBCmd.AddCommand(inspectCallsCmd)
+	for _, cmd := range []*cobra.Command{inspectOutliersCmd, inspectCallsCmd} {
+		sampleFlags := cmd.Flags()
+		sampleFlags.DurationVar(&sampleInterval, "interval", 0, "Report statistics sampled over this interval instead of since the last reset.")
+		sampleFlags.BoolVar(&sampleWatch, "watch", false, "Refresh sampled statistics continuously.")
+		inspectDBCmd.AddCommand(cmd)
+	}
 	inspectDBCmd.AddCommand(inspectTotalIndexSizeCmd)
 	inspectDBCmd.AddCommand(inspectIndexSizesCmd)
 	inspectDBCmd.AddCommand(inspectTableSizesCmd)
@@ -225,5 +383,101 @@ func init() {
 	inspectDBCmd.AddCommand(inspectBloatCmd)
 	inspectDBCmd.AddCommand(inspectVacuumStatsCmd)
 	inspectDBCmd.AddCommand(inspectRoleConnectionsCmd)
+	inspectIndexAdvisorCmd.Flags().StringVarP(&file, "file", "f", "", "Saves suggested indexes to a new migration file.")
+	inspectDBCmd.AddCommand(inspectIndexAdvisorCmd)
+	explainFlags := inspectExplainCmd.Flags()
+	explainFlags.StringVarP(&file, "file", "f", "", "Path to a SQL file containing the query to explain.")
+	explainFlags.BoolVar(&explainAnalyze, "analyze", false, "Execute the query to report actual run times. Changes are always rolled back.")
+	explainFlags.StringVar(&explainRole, "role", "", "Database role to run the query as, ie. anon or authenticated.")
+	explainFlags.StringVar(&explainClaims, "claims", "", "JWT claims in JSON format for evaluating RLS policies.")
+	cobra.CheckErr(inspectExplainCmd.MarkFlagRequired("file"))
+	inspectDBCmd.AddCommand(inspectExplainCmd)
+	inspectDBCmd.AddCommand(inspectSnapshotCmd)
+	inspectGrowthCmd.Flags().StringVar(&growthTarget, "target", "10GB", "Project when tables will reach this total size.")
+	inspectDBCmd.AddCommand(inspectGrowthCmd)
+	inspectPrivilegesCmd.Flags().StringSliceVarP(&schema, "schema", "s", []string{}, "Comma separated list of schema to include.")
+	inspectDBCmd.AddCommand(inspectPrivilegesCmd)
+	inspectCheckCmd.Flags().StringVar(&checkRules, "rules", "", "Path to a TOML file of thresholds, defaults to supabase/inspect.toml.")
+	inspectDBCmd.AddCommand(inspectCheckCmd)
+	reportFlags := inspectReportCmd.Flags()
+	reportFlags.Var(&reportFormat, "format", "Format of the generated report.")
+	reportFlags.StringVar(&reportOutputFile, "output-file", "", "File path to save the report.")
+	inspectDBCmd.AddCommand(inspectReportCmd)
+	defaultHelp := inspectDBCmd.HelpFunc()
+	inspectDBCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		if cmd == inspectDBCmd {
+			addCustomInspectCmds(afero.NewOsFs())
+		}
+		defaultHelp(cmd, args)
+	})
 	rootCmd.AddCommand(inspectCmd)
 }
+
+// Checks that the argument names a user defined query before connecting to the database.
+func validateCustomInspectCmd(cmd *cobra.Command, args []string) error {
+	if err := cobra.MaximumNArgs(1)(cmd, args); err != nil || len(args) == 0 {
+		return err
+	}
+	_, names, err := listCustomInspectCmds(afero.NewOsFs())
+	if err != nil {
+		return err
+	}
+	if !utils.SliceContains(names, args[0]) {
+		return errors.Errorf("unknown command %q for %q", args[0], cmd.CommandPath())
+	}
+	return nil
+}
+
+// Arguments, help and completion are resolved before the workdir is changed, so project root is resolved from --workdir flag.
+func listCustomInspectCmds(fsys afero.Fs) (afero.Fs, []string, error) {
+	workdir, err := filepath.Abs(viper.GetString("WORKDIR"))
+	if err != nil {
+		return nil, nil, errors.Errorf("failed to resolve workdir: %w", err)
+	}
+	if len(viper.GetString("WORKDIR")) == 0 {
+		if workdir, err = utils.GetProjectRoot(fsys); err != nil {
+			return nil, nil, err
+		}
+	}
+	fsys = afero.NewBasePathFs(fsys, workdir)
+	names, err := custom.List(fsys)
+	return fsys, names, err
+}
+
+// Lists user defined queries of the project as inspect subcommands in help output.
+func addCustomInspectCmds(fsys afero.Fs) {
+	fsys, names, err := listCustomInspectCmds(fsys)
+	if err != nil {
+		fmt.Fprintln(utils.GetDebugLogger(), err)
+		return
+	}
+	for _, name := range names {
+		// Built-in commands take precedence when both are defined
+		if c, _, err := inspectDBCmd.Find([]string{name}); err == nil && c != inspectDBCmd {
+			continue
+		}
+		query, err := custom.Load(name, fsys)
+		if err != nil {
+			fmt.Fprintln(utils.GetDebugLogger(), err)
+		}
+		queryName := name
+		inspectDBCmd.AddCommand(&cobra.Command{
+			Use:   queryName,
+			Short: query.Title,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return custom.Run(cmd.Context(), inspectOutput.Value, queryName, flags.DbConfig, afero.NewOsFs())
+			},
+		})
+	}
+}
+
+func completeCustomInspectCmds(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	_, names, err := listCustomInspectCmds(afero.NewOsFs())
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
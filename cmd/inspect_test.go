@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/utils"
+)
+
+func TestValidateSampleFlags(t *testing.T) {
+	t.Cleanup(func() {
+		sampleInterval = 0
+		sampleWatch = false
+		inspectOutput.Value = utils.OutputPretty
+		if f := inspectCallsCmd.Flags().Lookup("output"); f != nil {
+			f.Changed = false
+		}
+	})
+
+	t.Run("rejects negative interval", func(t *testing.T) {
+		sampleInterval = -time.Second
+		assert.ErrorContains(t, validateSampleFlags(inspectCallsCmd, nil), "Invalid interval: -1s")
+	})
+
+	t.Run("rejects watch with output", func(t *testing.T) {
+		sampleInterval = 0
+		require.NoError(t, inspectCallsCmd.ParseFlags([]string{"--watch", "-o", "json"}))
+		assert.ErrorContains(t, validateSampleFlags(inspectCallsCmd, nil), "--watch cannot be used with --output")
+	})
+}
+
+func TestSessionFlags(t *testing.T) {
+	for _, cmd := range []*cobra.Command{inspectBlockingCmd, inspectLongRunningQueriesCmd} {
+		t.Run("defaults to plain output for "+cmd.Name(), func(t *testing.T) {
+			// Run test
+			require.NoError(t, cmd.ParseFlags(nil))
+			// Check flags: neither interactive nor cancel paths are taken
+			assert.False(t, sessionInteractive)
+			assert.Zero(t, cancelOlderThan)
+			assert.Equal(t, "false", cmd.Flags().Lookup("interactive").DefValue)
+		})
+	}
+
+	t.Run("does not share interactive flag with projects create", func(t *testing.T) {
+		require.NoError(t, inspectBlockingCmd.ParseFlags(nil))
+		assert.True(t, interactive)
+		assert.False(t, sessionInteractive)
+	})
+}
@@ -6,8 +6,11 @@ import (
 
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"github.com/supabase/cli/internal/db/test"
+	"github.com/supabase/cli/internal/test/generate"
 	"github.com/supabase/cli/internal/test/new"
 	"github.com/supabase/cli/internal/utils"
+	"github.com/supabase/cli/internal/utils/flags"
 )
 
 var (
@@ -23,11 +26,34 @@ var (
 		RunE:  dbTestCmd.RunE,
 	}
 
+	testOutput = utils.EnumFlag{
+		Allowed: test.AllowedOutputs,
+		Value:   test.AllowedOutputs[0],
+	}
+	testOutputFile string
+	testJobs       uint
+	testCoverage   bool
+
+	testCoverageOutput = utils.EnumFlag{
+		Allowed: test.AllowedCoverageOutputs,
+		Value:   test.AllowedCoverageOutputs[0],
+	}
+	testCoverageFile string
+
 	template = utils.EnumFlag{
-		Allowed: []string{new.TemplatePgTAP},
+		Allowed: []string{new.TemplatePgTAP, new.TemplateRLS},
 		Value:   new.TemplatePgTAP,
 	}
 
+	testGenerateCmd = &cobra.Command{
+		Use:   "generate",
+		Short: "Generate pgTAP tests from the database schema",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, _ := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			return generate.Run(ctx, schema, flags.DbConfig, afero.NewOsFs())
+		},
+	}
+
 	testNewCmd = &cobra.Command{
 		Use:   "new <name>",
 		Short: "Create a new test file",
@@ -46,7 +72,24 @@ func init() {
 	dbFlags.Bool("linked", false, "Runs pgTAP tests on the linked project.")
 	dbFlags.Bool("local", true, "Runs pgTAP tests on the local database.")
 	testDbCmd.MarkFlagsMutuallyExclusive("db-url", "linked", "local")
+	dbFlags.Var(&testOutput, "output", "Output format of test results.")
+	dbFlags.StringVar(&testOutputFile, "output-file", "", "File path to save the test report.")
+	dbFlags.UintVarP(&testJobs, "jobs", "j", 1, "Number of database copies to run tests in parallel.")
+	dbFlags.BoolVar(&testCoverage, "coverage", false, "Report line coverage of PL/pgSQL functions and policies on unreferenced tables.")
+	dbFlags.Var(&testCoverageOutput, "coverage-output", "Output format of coverage report.")
+	dbFlags.StringVar(&testCoverageFile, "coverage-file", "", "File path to save the coverage report.")
+	dbFlags.BoolVar(&watch, "watch", false, "Re-run tests on changes to test files, migrations and seed file.")
+	testDbCmd.MarkFlagsMutuallyExclusive("watch", "coverage")
+	testDbCmd.MarkFlagsMutuallyExclusive("watch", "output-file")
 	testCmd.AddCommand(testDbCmd)
+	// Build generate command
+	generateFlags := testGenerateCmd.Flags()
+	generateFlags.String("db-url", "", "Generates tests from the database specified by the connection string (must be percent-encoded).")
+	generateFlags.Bool("linked", false, "Generates tests from the linked project.")
+	generateFlags.Bool("local", true, "Generates tests from the local database.")
+	testGenerateCmd.MarkFlagsMutuallyExclusive("db-url", "linked", "local")
+	generateFlags.StringSliceVarP(&schema, "schema", "s", []string{}, "Comma separated list of schema to include.")
+	testCmd.AddCommand(testGenerateCmd)
 	// Build new command
 	newFlags := testNewCmd.Flags()
 	newFlags.VarP(&template, "template", "t", "Template framework to generate.")
@@ -0,0 +1,209 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/utils"
+	"github.com/supabase/cli/internal/utils/pgxv5"
+)
+
+const (
+	LIST_PRIMARY_KEYS = `SELECT a.attname
+FROM pg_index i JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+WHERE i.indrelid = $1::regclass AND i.indisprimary
+ORDER BY array_position(i.indkey, a.attnum)`
+	LIST_COLUMNS = `SELECT attname FROM pg_attribute
+WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped AND attgenerated = ''
+ORDER BY attnum`
+)
+
+var ErrMissingKey = errors.New("table has no primary key")
+
+// Identifies a row by the quoted literals of its primary key columns.
+type rowKey string
+
+type tableData struct {
+	columns []string
+	keys    []int
+	rows    map[rowKey][]string
+	order   []rowKey
+}
+
+func RunData(ctx context.Context, tables []string, file string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	// Sanity checks.
+	if err := utils.LoadConfigFS(fsys); err != nil {
+		return err
+	}
+	if utils.IsLocalDatabase(config) {
+		return errors.New("Cannot diff data of the local database against itself. Pass in --linked or --db-url flag.")
+	}
+	local := pgconn.Config{
+		Host:     utils.Config.Hostname,
+		Port:     uint16(utils.Config.Db.Port),
+		User:     "postgres",
+		Password: utils.Config.Db.Password,
+		Database: "postgres",
+	}
+	source, err := utils.ConnectByConfig(ctx, local, options...)
+	if err != nil {
+		return err
+	}
+	defer source.Close(context.Background())
+	target, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer target.Close(context.Background())
+	out, err := DiffData(ctx, tables, source, target)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Finished "+utils.Aqua("supabase db diff --data")+".")
+	if len(out) == 0 {
+		fmt.Fprintln(os.Stderr, "No data changes found")
+		return nil
+	}
+	return SaveDiff(out, file, fsys)
+}
+
+// DiffData compares table rows by primary key, returning the statements
+// required to bring the source database in sync with the target.
+func DiffData(ctx context.Context, tables []string, source, target *pgx.Conn) (string, error) {
+	var result strings.Builder
+	for _, name := range tables {
+		fmt.Fprintln(os.Stderr, "Diffing table:", name)
+		table := toIdentifier(name)
+		before, err := loadTableData(ctx, table, source)
+		if err != nil {
+			return "", err
+		}
+		after, err := loadTableData(ctx, table, target)
+		if err != nil {
+			return "", err
+		}
+		if strings.Join(before.columns, ",") != strings.Join(after.columns, ",") {
+			return "", errors.Errorf("mismatched columns for table %s: %v != %v", name, before.columns, after.columns)
+		}
+		writeDataDiff(&result, table.Sanitize(), before, after)
+	}
+	return result.String(), nil
+}
+
+func toIdentifier(table string) pgx.Identifier {
+	if parts := strings.SplitN(table, ".", 2); len(parts) == 2 {
+		return pgx.Identifier(parts)
+	}
+	return pgx.Identifier{"public", table}
+}
+
+func loadTableData(ctx context.Context, table pgx.Identifier, conn *pgx.Conn) (tableData, error) {
+	var data tableData
+	name := table.Sanitize()
+	rows, err := conn.Query(ctx, LIST_PRIMARY_KEYS, name)
+	if err != nil {
+		return data, errors.Errorf("failed to list primary keys: %w", err)
+	}
+	keys, err := pgxv5.CollectStrings(rows)
+	if err != nil {
+		return data, err
+	}
+	if len(keys) == 0 {
+		return data, errors.Errorf("%w: %s", ErrMissingKey, name)
+	}
+	if rows, err = conn.Query(ctx, LIST_COLUMNS, name); err != nil {
+		return data, errors.Errorf("failed to list columns: %w", err)
+	}
+	if data.columns, err = pgxv5.CollectStrings(rows); err != nil {
+		return data, err
+	}
+	var selected, ordered []string
+	for i, col := range data.columns {
+		quoted := pgx.Identifier{col}.Sanitize()
+		selected = append(selected, "quote_nullable("+quoted+")")
+		for _, k := range keys {
+			if k == col {
+				data.keys = append(data.keys, i)
+			}
+		}
+	}
+	for _, k := range keys {
+		ordered = append(ordered, pgx.Identifier{k}.Sanitize())
+	}
+	// Quoting on the server avoids having to handle every possible column type
+	sql := fmt.Sprintf("SELECT %s FROM %s ORDER BY %s", strings.Join(selected, ", "), name, strings.Join(ordered, ", "))
+	if rows, err = conn.Query(ctx, sql); err != nil {
+		return data, errors.Errorf("failed to select rows: %w", err)
+	}
+	defer rows.Close()
+	data.rows = make(map[rowKey][]string)
+	for rows.Next() {
+		values := make([]string, len(data.columns))
+		dest := make([]interface{}, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return data, errors.Errorf("failed to scan row: %w", err)
+		}
+		key := data.keyOf(values)
+		data.rows[key] = values
+		data.order = append(data.order, key)
+	}
+	if err := rows.Err(); err != nil {
+		return data, errors.Errorf("failed to parse rows: %w", err)
+	}
+	return data, nil
+}
+
+func (t tableData) keyOf(values []string) rowKey {
+	var key []string
+	for _, i := range t.keys {
+		key = append(key, values[i])
+	}
+	return rowKey(strings.Join(key, ","))
+}
+
+func (t tableData) whereClause(values []string) string {
+	var conds []string
+	for _, i := range t.keys {
+		conds = append(conds, pgx.Identifier{t.columns[i]}.Sanitize()+" = "+values[i])
+	}
+	return strings.Join(conds, " AND ")
+}
+
+func writeDataDiff(w *strings.Builder, table string, before, after tableData) {
+	var columns []string
+	for _, col := range after.columns {
+		columns = append(columns, pgx.Identifier{col}.Sanitize())
+	}
+	// Deletes are emitted first to free up any unique constraints
+	for _, key := range before.order {
+		if _, ok := after.rows[key]; !ok {
+			fmt.Fprintf(w, "DELETE FROM %s WHERE %s;\n", table, before.whereClause(before.rows[key]))
+		}
+	}
+	for _, key := range after.order {
+		values := after.rows[key]
+		prev, ok := before.rows[key]
+		if !ok {
+			fmt.Fprintf(w, "INSERT INTO %s (%s) VALUES (%s);\n", table, strings.Join(columns, ", "), strings.Join(values, ", "))
+			continue
+		}
+		var changes []string
+		for i, v := range values {
+			if prev[i] != v {
+				changes = append(changes, columns[i]+" = "+v)
+			}
+		}
+		if len(changes) > 0 {
+			fmt.Fprintf(w, "UPDATE %s SET %s WHERE %s;\n", table, strings.Join(changes, ", "), after.whereClause(values))
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/supabase/cli/internal/utils"
+	"github.com/supabase/cli/internal/utils/parser"
+	"github.com/supabase/cli/internal/utils/pgxv5"
+)
+
+// Functions in managed schemas that are not owned by these roles are user defined.
+const LIST_OWNED_FUNCTIONS = `SELECT n.nspname || '.' || p.proname
+FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace
+WHERE n.nspname = ANY($1::text[]) AND NOT pg_get_userbyid(p.proowner) = ANY($2::text[])
+ORDER BY 1`
+
+var (
+	// Schemas owned by Supabase services that users commonly extend with their own objects.
+	ManagedSchemas = []string{
+		"auth",
+		"storage",
+	}
+	managedOwners = []string{
+		"supabase_admin",
+		"supabase_auth_admin",
+		"supabase_storage_admin",
+	}
+	// Statements that only touch objects created on top of the base definitions.
+	userObjectPattern = regexp.MustCompile(`(?is)^((create|alter|drop)\s+policy|(create|drop)\s+(or\s+replace\s+)?(constraint\s+)?trigger)\s`)
+	functionPattern   = regexp.MustCompile(`(?is)^(create\s+(or\s+replace\s+)?|drop\s+)function\s+(if\s+exists\s+)?"?([^\s".]+)"?\."?([^\s"(]+)"?`)
+	// Privileges on tables and schemas in managed schemas belong to the base definitions.
+	grantPattern = regexp.MustCompile(`(?is)^(grant|revoke)\s.*?\son\s+function\s+"?([^\s".]+)"?\."?([^\s"(]+)"?`)
+	// Triggers may execute functions defined by Supabase services.
+	executePattern     = regexp.MustCompile(`(?is)\sexecute\s+(function|procedure)\s+"?([^\s".]+)"?\."?([^\s"(]+)"?`)
+	managedRolePattern = regexp.MustCompile(`(?is)\s(to|from)\s.*\b"?supabase_\w+`)
+)
+
+// WithManagedObjects extends a differ to include user owned policies, triggers, grants
+// and functions in managed schemas, while ignoring the Supabase owned base definitions.
+func WithManagedObjects(differ DiffFunc, options ...func(*pgx.ConnConfig)) DiffFunc {
+	return func(ctx context.Context, source, target string, schema []string) (string, error) {
+		var out string
+		if len(schema) > 0 {
+			var err error
+			if out, err = differ(ctx, source, target, schema); err != nil {
+				return "", err
+			}
+		}
+		// Managed schemas that are already diffed in full would otherwise be output twice
+		var remaining []string
+		for _, s := range ManagedSchemas {
+			if !utils.SliceContains(schema, s) {
+				remaining = append(remaining, s)
+			}
+		}
+		if len(remaining) == 0 {
+			return out, nil
+		}
+		managed, err := differ(ctx, source, target, remaining)
+		if err != nil {
+			return "", err
+		}
+		// Functions may be created on either side of the diff
+		var owned []string
+		for _, url := range []string{source, target} {
+			functions, err := listOwnedFunctions(ctx, url, options...)
+			if err != nil {
+				return "", err
+			}
+			owned = append(owned, functions...)
+		}
+		filtered, err := FilterManagedObjects(managed, owned)
+		if err != nil {
+			return "", err
+		}
+		if len(filtered) == 0 {
+			return out, nil
+		}
+		if len(out) > 0 && !strings.HasSuffix(out, "\n\n") {
+			out += "\n"
+		}
+		return out + filtered, nil
+	}
+}
+
+func listOwnedFunctions(ctx context.Context, url string, options ...func(*pgx.ConnConfig)) ([]string, error) {
+	conn, err := utils.ConnectByUrl(ctx, url, options...)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(context.Background())
+	return ListOwnedFunctions(ctx, conn)
+}
+
+// ListOwnedFunctions returns the qualified names of user defined functions in managed schemas.
+func ListOwnedFunctions(ctx context.Context, conn *pgx.Conn) ([]string, error) {
+	rows, err := conn.Query(ctx, LIST_OWNED_FUNCTIONS, ManagedSchemas, managedOwners)
+	if err != nil {
+		return nil, errors.Errorf("failed to list owned functions: %w", err)
+	}
+	return pgxv5.CollectStrings(rows)
+}
+
+// FilterManagedObjects keeps only the statements in a managed schema diff or dump that
+// operate on user defined objects, ie. policies, triggers, and owned functions and their
+// grants. Privileges of Supabase roles are managed by the platform, so they are skipped.
+func FilterManagedObjects(out string, owned []string) (string, error) {
+	stats, err := parser.SplitAndTrim(strings.NewReader(out))
+	if err != nil {
+		return "", err
+	}
+	functions := make(map[string]struct{}, len(owned))
+	for _, name := range owned {
+		functions[name] = struct{}{}
+	}
+	var result strings.Builder
+	for _, line := range stats {
+		sql := trimLeadingComments(line)
+		if matches := functionPattern.FindStringSubmatch(sql); len(matches) > 5 {
+			if _, ok := functions[matches[4]+"."+matches[5]]; !ok {
+				continue
+			}
+		} else if matches := grantPattern.FindStringSubmatch(sql); len(matches) > 3 {
+			if _, ok := functions[matches[2]+"."+matches[3]]; !ok || managedRolePattern.MatchString(sql) {
+				continue
+			}
+		} else if !userObjectPattern.MatchString(sql) {
+			continue
+		} else if matches := executePattern.FindStringSubmatch(sql); len(matches) > 3 && utils.SliceContains(ManagedSchemas, matches[2]) {
+			if _, ok := functions[matches[2]+"."+matches[3]]; !ok {
+				continue
+			}
+		}
+		fmt.Fprintf(&result, "%s;\n\n", line)
+	}
+	return result.String(), nil
+}
+
+func trimLeadingComments(sql string) string {
+	for strings.HasPrefix(sql, "--") {
+		index := strings.IndexByte(sql, '\n')
+		if index < 0 {
+			return ""
+		}
+		sql = strings.TrimSpace(sql[index+1:])
+	}
+	return sql
+}
@@ -1,6 +1,7 @@
 package dump
 
 import (
+	"bytes"
 	"context"
 	_ "embed"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"github.com/docker/docker/api/types/network"
 	"github.com/go-errors/errors"
 	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/spf13/afero"
 	"github.com/supabase/cli/internal/utils"
 )
@@ -25,7 +27,7 @@ var (
 	dumpRoleScript string
 )
 
-func Run(ctx context.Context, path string, config pgconn.Config, schema, excludeTable []string, dataOnly, roleOnly, keepComments, useCopy, dryRun bool, fsys afero.Fs) error {
+func Run(ctx context.Context, path string, config pgconn.Config, schema, excludeTable []string, subset, splitDir string, dataOnly, roleOnly, keepComments, useCopy, anonymize, dryRun bool, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
 	// Initialize output stream
 	var outStream afero.File
 	if len(path) > 0 {
@@ -48,12 +50,36 @@ func Run(ctx context.Context, path string, config pgconn.Config, schema, exclude
 	}
 	if dataOnly {
 		fmt.Fprintf(os.Stderr, "Dumping data from %s database...\n", db)
-		return dumpData(ctx, config, schema, excludeTable, useCopy, dryRun, outStream)
+		rules, err := LoadMaskingRules(anonymize, fsys)
+		if err != nil {
+			return err
+		}
+		tables := groupByTable(rules, schema, excludeTable)
+		if len(subset) > 0 {
+			return dumpSubset(ctx, config, subset, excludeTable, tables, outStream, options...)
+		}
+		// Masking queries depend on column types, so they are built by connecting even on dry run
+		var maskingSQL string
+		var masked []maskedTable
+		if len(tables) > 0 {
+			if maskingSQL, masked, err = maskData(ctx, config, tables, options...); err != nil {
+				return err
+			}
+		}
+		return dumpData(ctx, config, schema, excludeTable, useCopy, dryRun, outStream, masked, maskingSQL)
 	} else if roleOnly {
 		fmt.Fprintf(os.Stderr, "Dumping roles from %s database...\n", db)
 		return dumpRole(ctx, config, keepComments, dryRun, outStream)
 	}
 	fmt.Fprintf(os.Stderr, "Dumping schemas from %s database...\n", db)
+	if len(splitDir) > 0 {
+		// Comments are required to identify each object in the dump
+		var buf bytes.Buffer
+		if err := DumpSchema(ctx, config, schema, true, false, &buf); err != nil {
+			return err
+		}
+		return SplitSchema(&buf, splitDir, fsys)
+	}
 	return DumpSchema(ctx, config, schema, keepComments, dryRun, outStream)
 }
 
@@ -68,10 +94,42 @@ func DumpSchema(ctx context.Context, config pgconn.Config, schema []string, keep
 	if !keepComments {
 		env = append(env, "EXTRA_SED=/^--/d")
 	}
-	return dump(ctx, config, dumpSchemaScript, env, dryRun, stdout)
+	return dump(ctx, config, dumpSchemaScript, env, nil, dryRun, stdout)
+}
+
+func maskData(ctx context.Context, config pgconn.Config, tables []maskedTable, options ...func(*pgx.ConnConfig)) (string, []maskedTable, error) {
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return "", nil, err
+	}
+	defer conn.Close(context.Background())
+	sql, masked, err := buildMaskingSQL(ctx, tables, conn)
+	if err != nil || len(masked) == 0 {
+		return "", nil, err
+	}
+	names := make([]string, len(masked))
+	for i, t := range masked {
+		names[i] = t.String()
+	}
+	fmt.Fprintln(os.Stderr, "Masking data in tables:", strings.Join(names, ","))
+	return sql, masked, nil
+}
+
+// Masked tables are dumped separately from pg_dump. Their names are passed as positional
+// arguments of the script to avoid word splitting of quoted identifiers.
+func maskedArgs(masked []maskedTable) []string {
+	var args []string
+	for _, t := range masked {
+		args = append(args, "--exclude-table-data="+pgx.Identifier{t.schema, t.name}.Sanitize())
+	}
+	return args
 }
 
-func dumpData(ctx context.Context, config pgconn.Config, schema, excludeTable []string, useCopy, dryRun bool, stdout io.Writer) error {
+func dumpData(ctx context.Context, config pgconn.Config, schema, excludeTable []string, useCopy, dryRun bool, stdout io.Writer, masked []maskedTable, maskingSQL string) error {
+	var env []string
+	if len(maskingSQL) > 0 {
+		env = append(env, "MASKING_SQL="+maskingSQL)
+	}
 	// We want to dump user data in auth, storage, etc. for migrating to new project
 	excludedSchemas := []string{
 		"information_schema",
@@ -102,7 +160,6 @@ func dumpData(ctx context.Context, config pgconn.Config, schema, excludeTable []
 		// "supabase_functions",
 		"supabase_migrations",
 	}
-	var env []string
 	if len(schema) > 0 {
 		env = append(env, "INCLUDED_SCHEMAS="+strings.Join(schema, "|"))
 	} else {
@@ -118,7 +175,7 @@ func dumpData(ctx context.Context, config pgconn.Config, schema, excludeTable []
 	if len(extraFlags) > 0 {
 		env = append(env, "EXTRA_FLAGS="+strings.Join(extraFlags, " "))
 	}
-	return dump(ctx, config, dumpDataScript, env, dryRun, stdout)
+	return dump(ctx, config, dumpDataScript, env, maskedArgs(masked), dryRun, stdout)
 }
 
 func dumpRole(ctx context.Context, config pgconn.Config, keepComments, dryRun bool, stdout io.Writer) error {
@@ -126,10 +183,10 @@ func dumpRole(ctx context.Context, config pgconn.Config, keepComments, dryRun bo
 	if !keepComments {
 		env = append(env, "EXTRA_SED=/^--/d")
 	}
-	return dump(ctx, config, dumpRoleScript, env, dryRun, stdout)
+	return dump(ctx, config, dumpRoleScript, env, nil, dryRun, stdout)
 }
 
-func dump(ctx context.Context, config pgconn.Config, script string, env []string, dryRun bool, stdout io.Writer) error {
+func dump(ctx context.Context, config pgconn.Config, script string, env, args []string, dryRun bool, stdout io.Writer) error {
 	allEnvs := append(env,
 		"PGHOST="+config.Host,
 		fmt.Sprintf("PGPORT=%d", config.Port),
@@ -140,21 +197,7 @@ func dump(ctx context.Context, config pgconn.Config, script string, env []string
 		"ALLOWED_CONFIGS="+strings.Join(utils.AllowedConfigs, "|"),
 	)
 	if dryRun {
-		envMap := make(map[string]string, len(allEnvs))
-		for _, e := range allEnvs {
-			index := strings.IndexByte(e, '=')
-			if index < 0 {
-				continue
-			}
-			envMap[e[:index]] = e[index+1:]
-		}
-		expanded := os.Expand(script, func(key string) string {
-			// Bash variable expansion is unsupported:
-			// https://github.com/golang/go/issues/47187
-			parts := strings.Split(key, ":")
-			return envMap[parts[0]]
-		})
-		fmt.Println(expanded)
+		fmt.Println(expandScript(script, allEnvs, args))
 		return nil
 	}
 	return utils.DockerRunOnceWithConfig(
@@ -162,7 +205,7 @@ func dump(ctx context.Context, config pgconn.Config, script string, env []string
 		container.Config{
 			Image: utils.Pg15Image,
 			Env:   allEnvs,
-			Cmd:   []string{"bash", "-c", script, "--"},
+			Cmd:   append([]string{"bash", "-c", script, "--"}, args...),
 		},
 		container.HostConfig{
 			NetworkMode: container.NetworkMode("host"),
@@ -173,3 +216,29 @@ func dump(ctx context.Context, config pgconn.Config, script string, env []string
 		os.Stderr,
 	)
 }
+
+var escapeQuoted = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
+// Expands the variables and positional arguments of a script for printing, escaping their values
+// so that multiline queries with quoted identifiers remain valid shell.
+func expandScript(script string, env, args []string) string {
+	envMap := make(map[string]string, len(env))
+	for _, e := range env {
+		index := strings.IndexByte(e, '=')
+		if index < 0 {
+			continue
+		}
+		envMap[e[:index]] = escapeQuoted.Replace(e[index+1:])
+	}
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+	}
+	script = strings.ReplaceAll(script, `"$@"`, strings.Join(quoted, " "))
+	return os.Expand(script, func(key string) string {
+		// Bash variable expansion is unsupported:
+		// https://github.com/golang/go/issues/47187
+		parts := strings.Split(key, ":")
+		return envMap[parts[0]]
+	})
+}
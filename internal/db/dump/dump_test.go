@@ -1,15 +1,21 @@
 package dump
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/supabase/cli/internal/testing/apitest"
+	"github.com/supabase/cli/internal/testing/pgtest"
 	"github.com/supabase/cli/internal/utils"
 	"gopkg.in/h2non/gock.v1"
 )
@@ -35,7 +41,7 @@ func TestPullCommand(t *testing.T) {
 		apitest.MockDockerStart(utils.Docker, imageUrl, containerId)
 		require.NoError(t, apitest.MockDockerLogs(utils.Docker, containerId, "hello world"))
 		// Run test
-		err := Run(context.Background(), "schema.sql", dbConfig, nil, nil, false, false, false, false, false, fsys)
+		err := Run(context.Background(), "schema.sql", dbConfig, nil, nil, "", "", false, false, false, false, false, false, fsys)
 		// Check error
 		assert.NoError(t, err)
 		assert.Empty(t, apitest.ListUnmatchedRequests())
@@ -54,7 +60,7 @@ func TestPullCommand(t *testing.T) {
 		apitest.MockDockerStart(utils.Docker, imageUrl, containerId)
 		require.NoError(t, apitest.MockDockerLogs(utils.Docker, containerId, "hello world"))
 		// Run test
-		err := Run(context.Background(), "", dbConfig, []string{"public"}, nil, false, false, false, false, false, fsys)
+		err := Run(context.Background(), "", dbConfig, []string{"public"}, nil, "", "", false, false, false, false, false, false, fsys)
 		// Check error
 		assert.NoError(t, err)
 		assert.Empty(t, apitest.ListUnmatchedRequests())
@@ -70,7 +76,7 @@ func TestPullCommand(t *testing.T) {
 			Get("/v" + utils.Docker.ClientVersion() + "/images").
 			Reply(http.StatusServiceUnavailable)
 		// Run test
-		err := Run(context.Background(), "", dbConfig, nil, nil, false, false, false, false, false, fsys)
+		err := Run(context.Background(), "", dbConfig, nil, nil, "", "", false, false, false, false, false, false, fsys)
 		// Check error
 		assert.ErrorContains(t, err, "request returned Service Unavailable for API route and version")
 		assert.Empty(t, apitest.ListUnmatchedRequests())
@@ -85,9 +91,448 @@ func TestPullCommand(t *testing.T) {
 		apitest.MockDockerStart(utils.Docker, imageUrl, containerId)
 		require.NoError(t, apitest.MockDockerLogs(utils.Docker, containerId, "hello world"))
 		// Run test
-		err := Run(context.Background(), "schema.sql", dbConfig, nil, nil, false, false, false, false, false, fsys)
+		err := Run(context.Background(), "schema.sql", dbConfig, nil, nil, "", "", false, false, false, false, false, false, fsys)
 		// Check error
 		assert.ErrorContains(t, err, "operation not permitted")
 		assert.Empty(t, apitest.ListUnmatchedRequests())
 	})
 }
+
+func TestMaskingRules(t *testing.T) {
+	t.Run("loads default rules", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		// Run test
+		rules, err := LoadMaskingRules(true, fsys)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, AuthUserRules, rules)
+	})
+
+	t.Run("overrides default rules", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, utils.MaskingRulesPath, []byte(`
+[rules."auth.users.email"]
+strategy = "hash"
+[rules."public.profiles.name"]
+strategy = "fixed"
+value = "Jane Doe"
+`), 0644))
+		// Run test
+		rules, err := LoadMaskingRules(true, fsys)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, MaskingRule{Strategy: MaskHash}, rules["auth.users.email"])
+		assert.Equal(t, MaskingRule{Strategy: MaskFixed, Value: "Jane Doe"}, rules["public.profiles.name"])
+		assert.Len(t, rules, len(AuthUserRules)+1)
+	})
+
+	t.Run("throws error on invalid strategy", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, utils.MaskingRulesPath, []byte(`
+[rules."public.profiles.name"]
+strategy = "shuffle"
+`), 0644))
+		// Run test
+		rules, err := LoadMaskingRules(false, fsys)
+		// Check error
+		assert.ErrorContains(t, err, "Invalid masking strategy")
+		assert.Nil(t, rules)
+	})
+
+	t.Run("throws error on invalid column", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, utils.MaskingRulesPath, []byte(`
+[rules."profiles.name"]
+strategy = "null"
+`), 0644))
+		// Run test
+		rules, err := LoadMaskingRules(false, fsys)
+		// Check error
+		assert.ErrorContains(t, err, "Invalid masking rule")
+		assert.Nil(t, rules)
+	})
+
+	t.Run("filters excluded tables", func(t *testing.T) {
+		rules := map[string]MaskingRule{
+			"auth.users.email":     {Strategy: MaskEmail},
+			"public.profiles.name": {Strategy: MaskNull},
+			"public.logs.ip":       {Strategy: MaskHash},
+		}
+		// Run test
+		tables := groupByTable(rules, []string{"public"}, []string{"public.logs"})
+		// Check result
+		assert.Equal(t, []maskedTable{{
+			schema: "public",
+			name:   "profiles",
+			rules:  map[string]MaskingRule{"name": {Strategy: MaskNull}},
+		}}, tables)
+	})
+}
+
+func TestMaskData(t *testing.T) {
+	imageUrl := utils.GetRegistryImageUrl(utils.Pg15Image)
+	const containerId = "test-container"
+
+	t.Run("dumps masked data", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(LIST_COLUMNS, `"auth"."identities"`).
+			Reply("SELECT 0").
+			Query(LIST_COLUMNS, `"auth"."users"`).
+			Reply("SELECT 2", []interface{}{"id", "uuid", ""}, []interface{}{"email", "character varying(255)", ""})
+		// Setup mock docker
+		require.NoError(t, apitest.MockDocker(utils.Docker))
+		defer gock.OffAll()
+		apitest.MockDockerStart(utils.Docker, imageUrl, containerId)
+		require.NoError(t, apitest.MockDockerLogs(utils.Docker, containerId, "hello world"))
+		// Run test
+		err := Run(context.Background(), "data.sql", dbConfig, nil, nil, "", "", true, false, false, false, true, false, fsys, conn.Intercept)
+		// Check error
+		assert.NoError(t, err)
+		assert.Empty(t, apitest.ListUnmatchedRequests())
+	})
+
+	t.Run("builds masking query on dry run", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(LIST_COLUMNS, `"auth"."identities"`).
+			Reply("SELECT 0").
+			Query(LIST_COLUMNS, `"auth"."users"`).
+			Reply("SELECT 1", []interface{}{"email", "character varying(255)", ""})
+		// Run test
+		err := Run(context.Background(), "data.sql", dbConfig, nil, nil, "", "", true, false, false, false, true, true, fsys, conn.Intercept)
+		// Check error
+		assert.NoError(t, err)
+		contents, err := afero.ReadFile(fsys, "data.sql")
+		assert.NoError(t, err)
+		assert.Empty(t, contents)
+	})
+
+	t.Run("throws error on unsupported masking strategy", func(t *testing.T) {
+		tables := []maskedTable{{
+			schema: "public",
+			name:   "profiles",
+			rules:  map[string]MaskingRule{"age": {Strategy: MaskHash}},
+		}}
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(LIST_COLUMNS, `"public"."profiles"`).
+			Reply("SELECT 1", []interface{}{"age", "integer", ""})
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectByConfig(ctx, dbConfig, conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		sql, masked, err := buildMaskingSQL(ctx, tables, mock)
+		// Check error
+		assert.ErrorContains(t, err, `Invalid masking rule for "public"."profiles"."age": hash strategy is not supported for column of type integer`)
+		assert.Empty(t, sql)
+		assert.Empty(t, masked)
+	})
+
+	t.Run("builds masking query", func(t *testing.T) {
+		tables := []maskedTable{{
+			schema: "public",
+			name:   "profiles",
+			rules: map[string]MaskingRule{
+				"email": {Strategy: MaskEmail},
+				"name":  {Strategy: MaskFixed, Value: "O'Brien"},
+				"bio":   {Strategy: MaskNull},
+			},
+		}}
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(LIST_COLUMNS, `"public"."profiles"`).
+			Reply("SELECT 3", []interface{}{"id", "bigint", "a"}, []interface{}{"email", "text", ""}, []interface{}{"name", "text", ""})
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectByConfig(ctx, dbConfig, conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		sql, masked, err := buildMaskingSQL(ctx, tables, mock)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, tables, masked)
+		assert.True(t, strings.HasPrefix(sql, `SELECT 'INSERT INTO "public"."profiles" ("id", "email", "name") OVERRIDING SYSTEM VALUE VALUES (' || concat_ws(', ', quote_nullable("id"), `))
+		assert.Contains(t, sql, `'user_' || left(md5("public"."profiles"."email"::text), 12) || '@example.com'`)
+		assert.Contains(t, sql, `ELSE 'O''Brien' END`)
+		assert.True(t, strings.HasSuffix(sql, `|| ');' FROM "public"."profiles";`))
+	})
+}
+
+func TestExpandScript(t *testing.T) {
+	t.Run("quotes positional arguments", func(t *testing.T) {
+		args := maskedArgs([]maskedTable{{schema: "public", name: "My Table"}, {schema: "public", name: "it's"}})
+		script := expandScript(`pg_dump "$@"`, nil, args)
+		assert.Equal(t, `pg_dump '--exclude-table-data="public"."My Table"' '--exclude-table-data="public"."it'\''s"'`, script)
+	})
+
+	t.Run("escapes variables in double quotes", func(t *testing.T) {
+		env := []string{"MASKING_SQL=SELECT 'x' || \"$col\" FROM `t`;"}
+		script := expandScript(`echo "$MASKING_SQL" "$@"`, env, nil)
+		assert.Equal(t, "echo \"SELECT 'x' || \\\"\\$col\\\" FROM \\`t\\`;\" ", script)
+	})
+}
+
+func TestMaskExpr(t *testing.T) {
+	t.Run("casts hash to uuid", func(t *testing.T) {
+		expr, err := toMaskExpr(`"id"`, "uuid", MaskingRule{Strategy: MaskHash})
+		assert.NoError(t, err)
+		assert.Equal(t, `md5("id"::text)::uuid`, expr)
+	})
+
+	t.Run("casts hash to varchar length", func(t *testing.T) {
+		expr, err := toMaskExpr(`"code"`, "character varying(8)", MaskingRule{Strategy: MaskHash})
+		assert.NoError(t, err)
+		assert.Equal(t, `(md5("code"::text))::character varying(8)`, expr)
+	})
+
+	t.Run("accepts schema qualified text types", func(t *testing.T) {
+		expr, err := toMaskExpr(`"email"`, "extensions.citext", MaskingRule{Strategy: MaskEmail})
+		assert.NoError(t, err)
+		assert.Contains(t, expr, `'@example.com'`)
+	})
+
+	t.Run("accepts fixed value for any type", func(t *testing.T) {
+		expr, err := toMaskExpr(`"meta"`, "jsonb", MaskingRule{Strategy: MaskFixed, Value: "{}"})
+		assert.NoError(t, err)
+		assert.Equal(t, `CASE WHEN "meta" IS NULL THEN NULL ELSE '{}' END`, expr)
+	})
+
+	for _, c := range []struct {
+		strategy   string
+		columnType string
+	}{
+		{MaskHash, "integer"},
+		{MaskHash, "jsonb"},
+		{MaskEmail, "uuid"},
+		{MaskPreserveFormat, "bigint"},
+	} {
+		t.Run("rejects "+c.strategy+" for "+c.columnType, func(t *testing.T) {
+			expr, err := toMaskExpr(`"col"`, c.columnType, MaskingRule{Strategy: c.strategy})
+			assert.ErrorContains(t, err, "strategy is not supported for column of type "+c.columnType)
+			assert.Empty(t, expr)
+		})
+	}
+}
+
+func TestSubsetData(t *testing.T) {
+	members := ForeignKey{
+		Child:         "public.members",
+		Parent:        "public.orgs",
+		ChildColumns:  []string{"org_id"},
+		ParentColumns: []string{"id"},
+	}
+
+	t.Run("dumps related rows in insertion order", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query("begin isolation level repeatable read").Reply("BEGIN").
+			Query(RESOLVE_TABLE, "orgs").
+			Reply("SELECT 1", []interface{}{"public.orgs"}).
+			Query(LIST_FOREIGN_KEYS).
+			Reply("SELECT 1", []interface{}{members.Child, members.Parent, members.ChildColumns, members.ParentColumns}).
+			Query(CREATE_SUBSET_TABLE).
+			Reply("CREATE TABLE").
+			Query(fmt.Sprintf(INSERT_ROOT_ROWS, "'public.orgs'", "public.orgs", "id = 1")).
+			Reply("INSERT 0 1").
+			Query(fmt.Sprintf(INSERT_CHILD_ROWS, "'public.members'", "public.members", "public.orgs", "c.org_id = p.id", "'public.orgs'")).
+			Reply("INSERT 0 2").
+			Query(fmt.Sprintf(INSERT_PARENT_ROWS, "'public.members'", "public.members", "'public.orgs'", "public.orgs", "c.org_id = p.id")).
+			Reply("INSERT 0 0").
+			Query(LIST_COLUMNS, "public.orgs").
+			Reply("SELECT 1", []interface{}{"id", "integer", ""}).
+			Query(`SELECT 'INSERT INTO public.orgs ("id") VALUES (' || concat_ws(', ', quote_nullable("id")) || ');' FROM public.orgs`+fmt.Sprintf(SELECT_SUBSET_ROWS, "'public.orgs'")).
+			Reply("SELECT 1", []interface{}{`INSERT INTO public.orgs ("id") VALUES ('1');`}).
+			Query(LIST_COLUMNS, "public.members").
+			Reply("SELECT 2", []interface{}{"org_id", "integer", ""}, []interface{}{"email", "text", ""}).
+			Query(`SELECT 'INSERT INTO public.members ("org_id", "email") VALUES (' || concat_ws(', ', quote_nullable("org_id"), quote_nullable(NULL)) || ');' FROM public.members`+fmt.Sprintf(SELECT_SUBSET_ROWS, "'public.members'")).
+			Reply("SELECT 1", []interface{}{`INSERT INTO public.members ("org_id", "email") VALUES ('1', NULL);`}).
+			Query("rollback").Reply("ROLLBACK")
+		masks := []maskedTable{{
+			schema: "public",
+			name:   "members",
+			rules:  map[string]MaskingRule{"email": {Strategy: MaskNull}},
+		}}
+		// Run test
+		var out bytes.Buffer
+		err := dumpSubset(context.Background(), dbConfig, "orgs WHERE id = 1", nil, masks, &out, conn.Intercept)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, `SET session_replication_role = replica;
+
+INSERT INTO public.orgs ("id") VALUES ('1');
+INSERT INTO public.members ("org_id", "email") VALUES ('1', NULL);
+
+RESET ALL;
+`, out.String())
+	})
+
+	t.Run("throws error on missing table", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query("begin isolation level repeatable read").Reply("BEGIN").
+			Query(RESOLVE_TABLE, "missing").
+			Reply("SELECT 0").
+			Query("rollback").Reply("ROLLBACK")
+		// Run test
+		err := dumpSubset(context.Background(), dbConfig, "missing", nil, nil, io.Discard, conn.Intercept)
+		// Check error
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("sorts parents before children", func(t *testing.T) {
+		fks := []ForeignKey{
+			members,
+			{Child: "public.orgs", Parent: "public.plans"},
+			{Child: "public.members", Parent: "public.members"},
+		}
+		// Run test
+		tables := SortTables([]string{"public.members", "public.orgs", "public.plans", "public.tags"}, fks)
+		// Check result
+		assert.Equal(t, []string{"public.plans", "public.tags", "public.orgs", "public.members"}, tables)
+	})
+}
+
+func TestSplitSchema(t *testing.T) {
+	const schemaDump = `SET statement_timeout = 0;
+SET client_encoding = 'UTF8';
+
+--
+-- Name: handle_new_user(); Type: FUNCTION; Schema: public; Owner: postgres
+--
+
+CREATE OR REPLACE FUNCTION "public"."handle_new_user"() RETURNS "trigger"
+    LANGUAGE "plpgsql"
+    AS $$
+--
+begin
+  return new;
+end;
+$$;
+
+--
+-- Name: users; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE IF NOT EXISTS "public"."users" (
+    "id" "uuid" NOT NULL
+);
+
+--
+-- Name: users users_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY "public"."users"
+    ADD CONSTRAINT "users_pkey" PRIMARY KEY ("id");
+
+--
+-- Name: users Enable read access; Type: POLICY; Schema: public; Owner: postgres
+--
+
+CREATE POLICY "Enable read access" ON "public"."users" FOR SELECT USING (true);
+
+--
+-- Name: TABLE users; Type: ACL; Schema: public; Owner: postgres
+--
+
+GRANT ALL ON TABLE "public"."users" TO "anon";
+
+--
+-- Name: COLUMN users.id; Type: COMMENT; Schema: public; Owner: postgres
+--
+
+COMMENT ON COLUMN "public"."users"."id" IS 'User id';
+
+RESET ALL;
+`
+
+	t.Run("writes one file per object", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, "schema_dump/tables/public.stale.sql", []byte("stale"), 0644))
+		require.NoError(t, afero.WriteFile(fsys, "schema_dump/functions/README.md", []byte("keep"), 0644))
+		require.NoError(t, afero.WriteFile(fsys, "schema_dump/.split-manifest", []byte("tables/public.stale.sql\n"), 0644))
+		// Run test
+		err := SplitSchema(strings.NewReader(schemaDump), "schema_dump", fsys)
+		// Check error
+		assert.NoError(t, err)
+		exists, err := afero.Exists(fsys, "schema_dump/tables/public.stale.sql")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+		exists, err = afero.Exists(fsys, "schema_dump/functions/README.md")
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		manifest, err := afero.ReadFile(fsys, "schema_dump/.split-manifest")
+		assert.NoError(t, err)
+		assert.Equal(t, `functions/public.handle_new_user.sql
+others/public.COLUMN_users.id.sql
+policies/public.users.sql
+tables/public.users.sql
+`, string(manifest))
+		functions, err := afero.ReadFile(fsys, "schema_dump/functions/public.handle_new_user.sql")
+		assert.NoError(t, err)
+		assert.Equal(t, `CREATE OR REPLACE FUNCTION "public"."handle_new_user"() RETURNS "trigger"
+    LANGUAGE "plpgsql"
+    AS $$
+--
+begin
+  return new;
+end;
+$$;
+`, string(functions))
+		tables, err := afero.ReadFile(fsys, "schema_dump/tables/public.users.sql")
+		assert.NoError(t, err)
+		assert.Equal(t, `CREATE TABLE IF NOT EXISTS "public"."users" (
+    "id" "uuid" NOT NULL
+);
+
+ALTER TABLE ONLY "public"."users"
+    ADD CONSTRAINT "users_pkey" PRIMARY KEY ("id");
+
+GRANT ALL ON TABLE "public"."users" TO "anon";
+`, string(tables))
+		policies, err := afero.ReadFile(fsys, "schema_dump/policies/public.users.sql")
+		assert.NoError(t, err)
+		assert.Equal(t, "CREATE POLICY \"Enable read access\" ON \"public\".\"users\" FOR SELECT USING (true);\n", string(policies))
+	})
+
+	t.Run("refuses non-empty directory without manifest", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, "schema_dump/functions/custom.sql", []byte("keep"), 0644))
+		// Run test
+		err := SplitSchema(strings.NewReader(schemaDump), "schema_dump", fsys)
+		// Check error
+		assert.ErrorContains(t, err, "Refusing to split schema into non-empty directory:")
+		data, err := afero.ReadFile(fsys, "schema_dump/functions/custom.sql")
+		assert.NoError(t, err)
+		assert.Equal(t, "keep", string(data))
+	})
+
+	t.Run("rejects manifest paths outside split directory", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, "schema_dump/.split-manifest", []byte("../config.toml\n"), 0644))
+		// Run test
+		err := SplitSchema(strings.NewReader(schemaDump), "schema_dump", fsys)
+		// Check error
+		assert.ErrorContains(t, err, "invalid path in split manifest: ../config.toml")
+	})
+}
@@ -0,0 +1,271 @@
+package dump
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const (
+	MaskHash           = "hash"
+	MaskEmail          = "email"
+	MaskNull           = "null"
+	MaskFixed          = "fixed"
+	MaskPreserveFormat = "preserve_format"
+
+	LIST_COLUMNS = `SELECT attname AS name, format_type(atttypid, atttypmod) AS type, attidentity::text AS identity
+FROM pg_attribute
+WHERE attrelid = to_regclass($1) AND attnum > 0 AND NOT attisdropped AND attgenerated = ''
+ORDER BY attnum`
+)
+
+type Column struct {
+	Name string
+	Type string
+	// Identity columns are generated by default (d) or always (a)
+	Identity string
+}
+
+// Implemented by both connections and transactions.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+func listColumns(ctx context.Context, name string, conn querier) ([]Column, error) {
+	rows, err := conn.Query(ctx, LIST_COLUMNS, name)
+	if err != nil {
+		return nil, errors.Errorf("failed to list columns: %w", err)
+	}
+	defer rows.Close()
+	var result []Column
+	for rows.Next() {
+		var c Column
+		if err := rows.Scan(&c.Name, &c.Type, &c.Identity); err != nil {
+			return nil, errors.Errorf("failed to scan columns: %w", err)
+		}
+		result = append(result, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Errorf("failed to list columns: %w", err)
+	}
+	return result, nil
+}
+
+type MaskingRule struct {
+	Strategy string `toml:"strategy"`
+	Value    string `toml:"value"`
+}
+
+type maskingConfig struct {
+	Rules map[string]MaskingRule `toml:"rules"`
+}
+
+var (
+	// Personal information stored by the auth service, masked when --anonymize flag is set.
+	AuthUserRules = map[string]MaskingRule{
+		"auth.users.email":              {Strategy: MaskEmail},
+		"auth.users.phone":              {Strategy: MaskPreserveFormat},
+		"auth.users.encrypted_password": {Strategy: MaskNull},
+		"auth.users.raw_user_meta_data": {Strategy: MaskFixed, Value: "{}"},
+		"auth.identities.identity_data": {Strategy: MaskFixed, Value: "{}"},
+	}
+	maskStrategies = []string{
+		MaskHash,
+		MaskEmail,
+		MaskNull,
+		MaskFixed,
+		MaskPreserveFormat,
+	}
+)
+
+// LoadMaskingRules reads masking rules keyed by schema.table.column from the project directory.
+func LoadMaskingRules(anonymize bool, fsys afero.Fs) (map[string]MaskingRule, error) {
+	rules := map[string]MaskingRule{}
+	if anonymize {
+		for k, v := range AuthUserRules {
+			rules[k] = v
+		}
+	}
+	var config maskingConfig
+	if _, err := toml.DecodeFS(afero.NewIOFS(fsys), utils.MaskingRulesPath, &config); errors.Is(err, os.ErrNotExist) {
+		return rules, nil
+	} else if err != nil {
+		return nil, errors.Errorf("failed to parse masking rules: %w", err)
+	}
+	// User defined rules take precedence over defaults
+	for k, v := range config.Rules {
+		if len(strings.Split(k, ".")) != 3 {
+			return nil, errors.Errorf("Invalid masking rule %s: must be in the form of schema.table.column", utils.Bold(k))
+		}
+		if !utils.SliceContains(maskStrategies, v.Strategy) {
+			return nil, errors.Errorf("Invalid masking strategy for %s: must be one of [ %s ]", utils.Bold(k), strings.Join(maskStrategies, " | "))
+		}
+		rules[k] = v
+	}
+	return rules, nil
+}
+
+type maskedTable struct {
+	schema string
+	name   string
+	rules  map[string]MaskingRule
+}
+
+func (t maskedTable) String() string {
+	return t.schema + "." + t.name
+}
+
+func groupByTable(rules map[string]MaskingRule, schema, excludeTable []string) []maskedTable {
+	tables := map[string]*maskedTable{}
+	for k, v := range rules {
+		parts := strings.Split(k, ".")
+		key := parts[0] + "." + parts[1]
+		if len(schema) > 0 && !utils.SliceContains(schema, parts[0]) || utils.SliceContains(excludeTable, key) {
+			continue
+		}
+		if _, ok := tables[key]; !ok {
+			tables[key] = &maskedTable{schema: parts[0], name: parts[1], rules: map[string]MaskingRule{}}
+		}
+		tables[key].rules[parts[2]] = v
+	}
+	var result []maskedTable
+	for _, t := range tables {
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].String() < result[j].String()
+	})
+	return result
+}
+
+// Builds a select statement per table which outputs masked rows as insert statements.
+func buildMaskingSQL(ctx context.Context, tables []maskedTable, conn *pgx.Conn) (string, []maskedTable, error) {
+	var queries []string
+	var masked []maskedTable
+	for _, t := range tables {
+		name := pgx.Identifier{t.schema, t.name}.Sanitize()
+		columns, err := listColumns(ctx, name, conn)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(columns) == 0 {
+			fmt.Fprintln(os.Stderr, "Skipping masking rules for missing table:", t)
+			continue
+		}
+		sql, err := selectInsertSQL(name, columns, t.rules)
+		if err != nil {
+			return "", nil, err
+		}
+		queries = append(queries, sql+";")
+		masked = append(masked, t)
+	}
+	return strings.Join(queries, "\n"), masked, nil
+}
+
+// Selects rows of a table as insert statements, with masking rules applied to matching columns.
+func selectInsertSQL(name string, columns []Column, rules map[string]MaskingRule) (string, error) {
+	var quoted, values []string
+	remaining := make(map[string]struct{}, len(rules))
+	for col := range rules {
+		remaining[col] = struct{}{}
+	}
+	var overriding string
+	for _, col := range columns {
+		ident := pgx.Identifier{col.Name}.Sanitize()
+		quoted = append(quoted, ident)
+		expr := ident
+		if r, ok := rules[col.Name]; ok {
+			// Qualified column name avoids conflicts with subquery aliases
+			var err error
+			if expr, err = toMaskExpr(name+"."+ident, col.Type, r); err != nil {
+				return "", errors.Errorf("Invalid masking rule for %s.%s: %w", name, ident, err)
+			}
+			delete(remaining, col.Name)
+		}
+		values = append(values, "quote_nullable("+expr+")")
+		// Identity columns generated always reject explicit values by default
+		if col.Identity == "a" {
+			overriding = " OVERRIDING SYSTEM VALUE"
+		}
+	}
+	for col := range remaining {
+		fmt.Fprintf(os.Stderr, "Skipping masking rule for missing column: %s.%s\n", name, col)
+	}
+	prefix := fmt.Sprintf("INSERT INTO %s (%s)%s VALUES (", name, strings.Join(quoted, ", "), overriding)
+	return fmt.Sprintf("SELECT %s || concat_ws(', ', %s) || ');' FROM %s", quoteLiteral(prefix), strings.Join(values, ", "), name), nil
+}
+
+// Masked values are computed as text, so they can only be restored to columns of compatible types.
+func toMaskExpr(column, columnType string, rule MaskingRule) (string, error) {
+	switch rule.Strategy {
+	case MaskHash:
+		// A md5 hash is also a valid uuid
+		if baseType(columnType) == "uuid" {
+			return fmt.Sprintf("md5(%s::text)::uuid", column), nil
+		}
+		if !isTextType(columnType) {
+			return "", errors.Errorf("%s strategy is not supported for column of type %s", rule.Strategy, columnType)
+		}
+		return castText(fmt.Sprintf("md5(%s::text)", column), columnType), nil
+	case MaskEmail:
+		if !isTextType(columnType) {
+			return "", errors.Errorf("%s strategy is not supported for column of type %s", rule.Strategy, columnType)
+		}
+		return castText(fmt.Sprintf("CASE WHEN %[1]s IS NULL THEN NULL ELSE 'user_' || left(md5(%[1]s::text), 12) || '@example.com' END", column), columnType), nil
+	case MaskNull:
+		return "NULL", nil
+	case MaskFixed:
+		// Literals are coerced to the column type when restored
+		return fmt.Sprintf("CASE WHEN %s IS NULL THEN NULL ELSE %s END", column, quoteLiteral(rule.Value)), nil
+	case MaskPreserveFormat:
+		if !isTextType(columnType) {
+			return "", errors.Errorf("%s strategy is not supported for column of type %s", rule.Strategy, columnType)
+		}
+		// Replaces each letter and digit with one derived from the hash of the value and position
+		return fmt.Sprintf(`(SELECT string_agg(CASE
+  WHEN c ~ '[0-9]' THEN chr(48 + get_byte(decode(md5(%[1]s::text || i), 'hex'), 0) %% 10)
+  WHEN c ~ '[a-z]' THEN chr(97 + get_byte(decode(md5(%[1]s::text || i), 'hex'), 0) %% 26)
+  WHEN c ~ '[A-Z]' THEN chr(65 + get_byte(decode(md5(%[1]s::text || i), 'hex'), 0) %% 26)
+  ELSE c END, '' ORDER BY i) FROM unnest(string_to_array(%[1]s::text, NULL)) WITH ORDINALITY AS _mask(c, i))`, column), nil
+	}
+	return column, nil
+}
+
+// Strips the schema and type modifiers, ie. extensions.citext or character varying(255).
+func baseType(columnType string) string {
+	if i := strings.IndexByte(columnType, '('); i >= 0 {
+		columnType = columnType[:i]
+	}
+	if i := strings.LastIndexByte(columnType, '.'); i >= 0 {
+		columnType = columnType[i+1:]
+	}
+	return strings.Trim(columnType, `"`)
+}
+
+func isTextType(columnType string) bool {
+	switch baseType(columnType) {
+	case "text", "character varying", "character", "citext", "name":
+		return true
+	}
+	return false
+}
+
+// Explicit casts truncate masked values that exceed the length of varchar columns.
+func castText(expr, columnType string) string {
+	if strings.Contains(columnType, "(") {
+		return fmt.Sprintf("(%s)::%s", expr, columnType)
+	}
+	return expr
+}
+
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,221 @@
+package dump
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/utils"
+)
+
+// Table of contents entry emitted by pg_dump before each object.
+var tocPattern = regexp.MustCompile(`^-- Name: (.+); Type: (.+); Schema: (.+); Owner: ([^;]+)(?:; Tablespace: .*)?$`)
+
+var (
+	// Objects are grouped by the directory of their parent object.
+	objectDirs = map[string]string{
+		"SCHEMA":            "schemas",
+		"EXTENSION":         "extensions",
+		"TYPE":              "types",
+		"DOMAIN":            "types",
+		"TABLE":             "tables",
+		"TABLE ATTACH":      "tables",
+		"DEFAULT":           "tables",
+		"CONSTRAINT":        "tables",
+		"FK CONSTRAINT":     "tables",
+		"ROW SECURITY":      "tables",
+		"SEQUENCE":          "sequences",
+		"SEQUENCE OWNED BY": "sequences",
+		"VIEW":              "views",
+		"MATERIALIZED VIEW": "views",
+		"FUNCTION":          "functions",
+		"PROCEDURE":         "functions",
+		"AGGREGATE":         "functions",
+		"INDEX":             "indexes",
+		"TRIGGER":           "triggers",
+		"POLICY":            "policies",
+	}
+	// Grants and comments are named after the type of object they apply to, ie. TABLE users.
+	aclTypes = []string{"ACL", "COMMENT"}
+	otherDir = "others"
+	// Lists the files written by the previous split, relative to the split directory.
+	manifestFile = ".split-manifest"
+	nameCleaner  = strings.NewReplacer("/", "_", `"`, "", " ", "_")
+)
+
+type dumpSection struct {
+	kind   string
+	schema string
+	name   string
+	lines  []string
+}
+
+// Returns the file path of an object relative to the split directory.
+func (s dumpSection) path() string {
+	kind, name := s.kind, s.name
+	if utils.SliceContains(aclTypes, kind) {
+		// Longest prefix wins, ie. MATERIALIZED VIEW over VIEW
+		var parent string
+		for k := range objectDirs {
+			if strings.HasPrefix(name, k+" ") && len(k) > len(parent) {
+				parent = k
+			}
+		}
+		if len(parent) > 0 {
+			kind, name = parent, strings.TrimPrefix(name, parent+" ")
+		}
+	}
+	dir, ok := objectDirs[kind]
+	if !ok {
+		dir = otherDir
+	}
+	switch kind {
+	case "DEFAULT", "CONSTRAINT", "FK CONSTRAINT", "TRIGGER", "POLICY":
+		// Named after the table followed by the object name
+		name, _, _ = strings.Cut(name, " ")
+	case "FUNCTION", "PROCEDURE", "AGGREGATE":
+		// Overloaded functions are saved to the same file
+		name, _, _ = strings.Cut(name, "(")
+	}
+	if s.schema != "-" {
+		name = s.schema + "." + name
+	}
+	name = nameCleaner.Replace(name)
+	return filepath.Join(dir, name+".sql")
+}
+
+// SplitSchema writes each object in a pg_dump schema stream to its own file,
+// ie. tables/public.users.sql, under the split directory.
+func SplitSchema(r io.Reader, splitDir string, fsys afero.Fs) error {
+	sections, err := parseSections(r)
+	if err != nil {
+		return err
+	}
+	// Objects are appended to files in the same order as pg_dump
+	files := map[string][]string{}
+	var paths []string
+	for _, s := range sections {
+		if len(s.lines) == 0 {
+			continue
+		}
+		p := s.path()
+		if _, ok := files[p]; !ok {
+			paths = append(paths, p)
+		}
+		files[p] = append(files[p], strings.Join(s.lines, "\n"))
+	}
+	// Remove stale files from previous dumps
+	if err := removeSplitFiles(splitDir, fsys); err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		dst := filepath.Join(splitDir, p)
+		contents := strings.Join(files[p], "\n\n") + "\n"
+		if err := utils.WriteFile(dst, []byte(contents), fsys); err != nil {
+			return err
+		}
+	}
+	manifest := strings.Join(paths, "\n") + "\n"
+	if err := utils.WriteFile(filepath.Join(splitDir, manifestFile), []byte(manifest), fsys); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Dumped %d objects to %d files in %s\n", len(sections), len(paths), utils.Bold(splitDir))
+	return nil
+}
+
+// Only files listed in the manifest are removed, so that a split directory is
+// never mistaken for one containing user files.
+func removeSplitFiles(splitDir string, fsys afero.Fs) error {
+	manifestPath := filepath.Join(splitDir, manifestFile)
+	data, err := afero.ReadFile(fsys, manifestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		if empty, err := isEmptyDir(splitDir, fsys); err != nil {
+			return err
+		} else if !empty {
+			return errors.Errorf("Refusing to split schema into non-empty directory: %s", utils.Bold(splitDir))
+		}
+		return nil
+	} else if err != nil {
+		return errors.Errorf("failed to read split manifest: %w", err)
+	}
+	for _, p := range strings.Split(string(data), "\n") {
+		if p = strings.TrimSpace(p); len(p) == 0 {
+			continue
+		}
+		// Guards against manifests edited to point outside the split directory
+		if !filepath.IsLocal(p) {
+			return errors.Errorf("invalid path in split manifest: %s", p)
+		}
+		if err := fsys.Remove(filepath.Join(splitDir, p)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.Errorf("failed to remove file: %w", err)
+		}
+	}
+	return nil
+}
+
+func isEmptyDir(dir string, fsys afero.Fs) (bool, error) {
+	entries, err := afero.ReadDir(fsys, dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return true, nil
+	} else if err != nil {
+		return false, errors.Errorf("failed to read split directory: %w", err)
+	}
+	return len(entries) == 0, nil
+}
+
+func parseSections(r io.Reader) ([]dumpSection, error) {
+	var sections []dumpSection
+	scanner := bufio.NewScanner(r)
+	// Function bodies may contain very long lines
+	scanner.Buffer(nil, 1024*1024*100)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if matches := tocPattern.FindStringSubmatch(line); len(matches) > 4 {
+			sections = append(sections, dumpSection{
+				kind:   matches[2],
+				schema: matches[3],
+				name:   matches[1],
+			})
+			continue
+		}
+		// Lines before the first object only set session configs
+		if len(sections) == 0 {
+			continue
+		}
+		current := &sections[len(sections)-1]
+		if len(current.lines) == 0 && (len(line) == 0 || line == "--") {
+			continue
+		}
+		current.lines = append(current.lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Errorf("failed to read schema dump: %w", err)
+	}
+	for i := range sections {
+		sections[i].lines = trimTrailing(sections[i].lines)
+	}
+	// Session config is reset at the end of the dump
+	if n := len(sections); n > 0 {
+		lines := sections[n-1].lines
+		if k := len(lines); k > 0 && lines[k-1] == "RESET ALL;" {
+			sections[n-1].lines = trimTrailing(lines[:k-1])
+		}
+	}
+	return sections, nil
+}
+
+// Removes blank lines and the comment block preceding the next object.
+func trimTrailing(lines []string) []string {
+	for n := len(lines); n > 0 && (len(lines[n-1]) == 0 || lines[n-1] == "--"); n = len(lines) {
+		lines = lines[:n-1]
+	}
+	return lines
+}
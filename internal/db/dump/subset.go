@@ -0,0 +1,250 @@
+package dump
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/supabase/cli/internal/utils"
+	"github.com/supabase/cli/internal/utils/pgxv5"
+)
+
+const (
+	RESOLVE_TABLE = `SELECT format('%I.%I', n.nspname, c.relname)
+FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.oid = to_regclass($1)`
+	LIST_FOREIGN_KEYS = `SELECT
+  format('%I.%I', cn.nspname, cl.relname),
+  format('%I.%I', pn.nspname, pl.relname),
+  array(SELECT quote_ident(a.attname) FROM unnest(c.conkey) WITH ORDINALITY k(n, i) JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.n ORDER BY k.i),
+  array(SELECT quote_ident(a.attname) FROM unnest(c.confkey) WITH ORDINALITY k(n, i) JOIN pg_attribute a ON a.attrelid = c.confrelid AND a.attnum = k.n ORDER BY k.i)
+FROM pg_constraint c
+JOIN pg_class cl ON cl.oid = c.conrelid JOIN pg_namespace cn ON cn.oid = cl.relnamespace
+JOIN pg_class pl ON pl.oid = c.confrelid JOIN pg_namespace pn ON pn.oid = pl.relnamespace
+WHERE c.contype = 'f'
+ORDER BY 1, 2`
+	CREATE_SUBSET_TABLE = "CREATE TEMP TABLE subset_rows (relid regclass, row_ctid tid, PRIMARY KEY (relid, row_ctid)) ON COMMIT DROP"
+	INSERT_ROOT_ROWS    = "INSERT INTO subset_rows SELECT %[1]s::regclass, ctid FROM %[2]s WHERE %[3]s ON CONFLICT DO NOTHING"
+	INSERT_CHILD_ROWS   = `INSERT INTO subset_rows SELECT %[1]s::regclass, c.ctid FROM %[2]s c JOIN %[3]s p ON %[4]s
+JOIN subset_rows s ON s.relid = %[5]s::regclass AND s.row_ctid = p.ctid ON CONFLICT DO NOTHING`
+	INSERT_PARENT_ROWS = `INSERT INTO subset_rows SELECT %[3]s::regclass, p.ctid FROM %[4]s p JOIN %[2]s c ON %[5]s
+JOIN subset_rows s ON s.relid = %[1]s::regclass AND s.row_ctid = c.ctid ON CONFLICT DO NOTHING`
+	SELECT_SUBSET_ROWS = " WHERE ctid IN (SELECT row_ctid FROM subset_rows WHERE relid = %s::regclass)"
+)
+
+var subsetPattern = regexp.MustCompile(`(?is)^\s*(\S+)(?:\s+where\s+(.+))?$`)
+
+type ForeignKey struct {
+	Child         string
+	Parent        string
+	ChildColumns  []string
+	ParentColumns []string
+}
+
+func (fk ForeignKey) joinCondition() string {
+	var conds []string
+	for i, col := range fk.ChildColumns {
+		conds = append(conds, fmt.Sprintf("c.%s = p.%s", col, fk.ParentColumns[i]))
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// Walks foreign keys from the root rows to collect a referentially consistent subset of data.
+func dumpSubset(ctx context.Context, config pgconn.Config, root string, excludeTable []string, masks []maskedTable, stdout io.Writer, options ...func(*pgx.ConnConfig)) error {
+	matches := subsetPattern.FindStringSubmatch(root)
+	if len(matches) < 3 {
+		return errors.Errorf("Invalid subset query: %s", root)
+	}
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	// Temporary table is dropped on rollback
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
+	if err != nil {
+		return errors.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(context.Background()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+	var table string
+	if err := tx.QueryRow(ctx, RESOLVE_TABLE, matches[1]).Scan(&table); err != nil {
+		return errors.Errorf("failed to resolve table %s: %w", matches[1], err)
+	}
+	fks, err := listForeignKeys(ctx, tx)
+	if err != nil {
+		return err
+	}
+	var included []ForeignKey
+	for _, fk := range fks {
+		if !utils.SliceContains(excludeTable, fk.Child) && !utils.SliceContains(excludeTable, fk.Parent) {
+			included = append(included, fk)
+		}
+	}
+	if _, err := tx.Exec(ctx, CREATE_SUBSET_TABLE); err != nil {
+		return errors.Errorf("failed to create subset table: %w", err)
+	}
+	selected, err := collectRows(ctx, tx, table, matches[2], included)
+	if err != nil {
+		return err
+	}
+	rules := map[string]map[string]MaskingRule{}
+	for _, t := range masks {
+		rules[t.String()] = t.rules
+	}
+	fmt.Fprintln(stdout, "SET session_replication_role = replica;")
+	fmt.Fprintln(stdout)
+	for _, name := range SortTables(selected, included) {
+		fmt.Fprintln(os.Stderr, "Dumping subset of table:", name)
+		columns, err := listColumns(ctx, name, tx)
+		if err != nil {
+			return err
+		}
+		sql, err := selectInsertSQL(name, columns, rules[name])
+		if err != nil {
+			return err
+		}
+		rows, err := tx.Query(ctx, sql+fmt.Sprintf(SELECT_SUBSET_ROWS, quoteLiteral(name)))
+		if err != nil {
+			return errors.Errorf("failed to select subset: %w", err)
+		}
+		inserts, err := pgxv5.CollectStrings(rows)
+		if err != nil {
+			return err
+		}
+		for _, line := range inserts {
+			fmt.Fprintln(stdout, line)
+		}
+	}
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, "RESET ALL;")
+	return nil
+}
+
+func listForeignKeys(ctx context.Context, tx pgx.Tx) ([]ForeignKey, error) {
+	rows, err := tx.Query(ctx, LIST_FOREIGN_KEYS)
+	if err != nil {
+		return nil, errors.Errorf("failed to list foreign keys: %w", err)
+	}
+	defer rows.Close()
+	var result []ForeignKey
+	for rows.Next() {
+		var fk ForeignKey
+		if err := rows.Scan(&fk.Child, &fk.Parent, &fk.ChildColumns, &fk.ParentColumns); err != nil {
+			return nil, errors.Errorf("failed to scan foreign key: %w", err)
+		}
+		result = append(result, fk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Errorf("failed to parse foreign keys: %w", err)
+	}
+	return result, nil
+}
+
+func collectRows(ctx context.Context, tx pgx.Tx, root, where string, fks []ForeignKey) ([]string, error) {
+	if len(where) == 0 {
+		where = "true"
+	}
+	sql := fmt.Sprintf(INSERT_ROOT_ROWS, quoteLiteral(root), root, where)
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return nil, errors.Errorf("failed to select root rows: %w", err)
+	}
+	selected := map[string]struct{}{root: {}}
+	// 1. Dependent rows are collected by walking from parent to child tables
+	queue := []string{root}
+	for len(queue) > 0 {
+		table := queue[0]
+		queue = queue[1:]
+		for _, fk := range fks {
+			if fk.Parent != table {
+				continue
+			}
+			sql := fmt.Sprintf(INSERT_CHILD_ROWS, quoteLiteral(fk.Child), fk.Child, fk.Parent, fk.joinCondition(), quoteLiteral(fk.Parent))
+			if added, err := tx.Exec(ctx, sql); err != nil {
+				return nil, errors.Errorf("failed to select dependent rows: %w", err)
+			} else if added.RowsAffected() > 0 {
+				selected[fk.Child] = struct{}{}
+				queue = append(queue, fk.Child)
+			}
+		}
+	}
+	// 2. Referenced rows are collected by walking from child to parent tables
+	for table := range selected {
+		queue = append(queue, table)
+	}
+	sort.Strings(queue)
+	for len(queue) > 0 {
+		table := queue[0]
+		queue = queue[1:]
+		for _, fk := range fks {
+			if fk.Child != table {
+				continue
+			}
+			sql := fmt.Sprintf(INSERT_PARENT_ROWS, quoteLiteral(fk.Child), fk.Child, quoteLiteral(fk.Parent), fk.Parent, fk.joinCondition())
+			if added, err := tx.Exec(ctx, sql); err != nil {
+				return nil, errors.Errorf("failed to select referenced rows: %w", err)
+			} else if added.RowsAffected() > 0 {
+				selected[fk.Parent] = struct{}{}
+				queue = append(queue, fk.Parent)
+			}
+		}
+	}
+	var result []string
+	for table := range selected {
+		result = append(result, table)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// SortTables orders tables such that referenced tables are inserted before their dependents.
+// Tables in a reference cycle are appended in alphabetical order.
+func SortTables(tables []string, fks []ForeignKey) []string {
+	parents := map[string]map[string]struct{}{}
+	for _, t := range tables {
+		parents[t] = map[string]struct{}{}
+	}
+	for _, fk := range fks {
+		if deps, ok := parents[fk.Child]; ok && fk.Child != fk.Parent {
+			if _, ok := parents[fk.Parent]; ok {
+				deps[fk.Parent] = struct{}{}
+			}
+		}
+	}
+	var result []string
+	for len(parents) > 0 {
+		var ready []string
+		for t, deps := range parents {
+			if len(deps) == 0 {
+				ready = append(ready, t)
+			}
+		}
+		if len(ready) == 0 {
+			// Break cycles by picking the first remaining table
+			for t := range parents {
+				ready = append(ready, t)
+			}
+			sort.Strings(ready)
+			ready = ready[:1]
+		}
+		sort.Strings(ready)
+		for _, t := range ready {
+			delete(parents, t)
+			for _, deps := range parents {
+				delete(deps, t)
+			}
+		}
+		result = append(result, ready...)
+	}
+	return result
+}
@@ -27,7 +27,13 @@ pg_dump \
     --exclude-table "storage.migrations" \
     --exclude-table "supabase_functions.migrations" \
     --schema "$INCLUDED_SCHEMAS" \
-    ${EXTRA_FLAGS:-}
+    ${EXTRA_FLAGS:-} \
+    "$@"
+
+# Masked tables are selected as insert statements with masking rules applied
+if [ -n "${MASKING_SQL:-}" ]; then
+    echo "$MASKING_SQL" | psql --no-psqlrc --tuples-only --no-align --quiet --set ON_ERROR_STOP=on
+fi
 
 # Reset session config generated by pg_dump
 echo "RESET ALL;"
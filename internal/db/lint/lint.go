@@ -39,8 +39,12 @@ func toEnum(level string) LintLevel {
 	return -1
 }
 
-func Run(ctx context.Context, schema []string, level string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+func Run(ctx context.Context, schema, include, exclude []string, level, failOn, output string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
 	// Sanity checks.
+	rules, err := SelectRules(include, exclude)
+	if err != nil {
+		return err
+	}
 	conn, err := utils.ConnectByConfig(ctx, config, options...)
 	if err != nil {
 		return err
@@ -51,16 +55,50 @@ func Run(ctx context.Context, schema []string, level string, config pgconn.Confi
 	if err != nil {
 		return err
 	}
+	advice, err := AdviseDatabase(ctx, conn, schema, exposedSchemas(), rules)
+	if err != nil {
+		return err
+	}
+	result = append(result, advice...)
+	if result, err = MapSources(result, fsys); err != nil {
+		return err
+	}
+	return reportResult(result, level, failOn, output, os.Stdout)
+}
+
+func reportResult(result []Result, level, failOn, output string, stdout io.Writer) error {
+	// Issues hidden by level are never counted towards failure
+	result = filterResult(result, toEnum(level))
 	if len(result) == 0 {
 		fmt.Fprintln(os.Stderr, "\nNo schema errors found")
+		// Report formats are always written for consumption by CI
+		if output == utils.OutputJson {
+			return nil
+		}
+	}
+	if err := printResult(result, toEnum(level), output, stdout); err != nil {
+		return err
+	}
+	if len(failOn) == 0 {
 		return nil
 	}
-	return printResultJSON(result, toEnum(level), os.Stdout)
+	if count := countIssues(result, toEnum(failOn)); count > 0 {
+		return errors.Errorf("Found %d issues at %s level or above.", count, failOn)
+	}
+	return nil
+}
+
+// Schemas served by the Data API are only known when config is loaded, ie. local or linked.
+func exposedSchemas() []string {
+	if len(utils.Config.Api.Schemas) > 0 {
+		return utils.Config.Api.Schemas
+	}
+	return []string{"public"}
 }
 
 func filterResult(result []Result, minLevel LintLevel) (filtered []Result) {
 	for _, r := range result {
-		out := Result{Function: r.Function}
+		out := Result{Function: r.Function, Object: r.Object, File: r.File, Line: r.Line, bodyLine: r.bodyLine}
 		for _, issue := range r.Issues {
 			if toEnum(issue.Level) >= minLevel {
 				out.Issues = append(out.Issues, issue)
@@ -163,9 +201,23 @@ type Issue struct {
 	Detail    string     `json:"detail,omitempty"`
 	Context   string     `json:"context,omitempty"`
 	SQLState  string     `json:"sqlState,omitempty"`
+	Rule      string     `json:"rule,omitempty"`
 }
 
 type Result struct {
-	Function string  `json:"function"`
+	Function string `json:"function,omitempty"`
+	// Tables, views and indexes reported by catalog rules
+	Object   string  `json:"object,omitempty"`
+	File     string  `json:"file,omitempty"`
+	Line     int     `json:"line,omitempty"`
 	Issues   []Issue `json:"issues"`
+	bodyLine int
+}
+
+// Name returns the qualified name of the function or object with issues.
+func (r Result) Name() string {
+	if len(r.Function) > 0 {
+		return r.Function
+	}
+	return r.Object
 }
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"net/http"
+	"path/filepath"
 	"testing"
 
 	"github.com/docker/docker/api/types"
@@ -65,7 +67,7 @@ func TestLintCommand(t *testing.T) {
 		Reply("SELECT 1", []interface{}{"f1", string(data)}).
 		Query("rollback").Reply("ROLLBACK")
 	// Run test
-	err = Run(context.Background(), []string{"public"}, "warning", dbConfig, fsys, conn.Intercept)
+	err = Run(context.Background(), []string{"public"}, nil, nil, "warning", "", "json", dbConfig, fsys, conn.Intercept)
 	// Check error
 	assert.NoError(t, err)
 	assert.Empty(t, apitest.ListUnmatchedRequests())
@@ -218,6 +220,157 @@ func TestLintDatabase(t *testing.T) {
 	})
 }
 
+func TestAdviseDatabase(t *testing.T) {
+	t.Run("reports rule violations", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		for _, r := range Rules {
+			sql, err := r.Query()
+			require.NoError(t, err)
+			switch r.Id {
+			case "security_definer_view":
+				// Skipped on Postgres 14
+				continue
+			case "rls_disabled_in_public":
+				conn.Query(sql, []string{"public"}).
+					Reply("SELECT 1", []interface{}{"public.todos", "Row level security is disabled."})
+			case "duplicate_index":
+				conn.Query(sql, []string{"private", "public"}).
+					Reply("SELECT 1", []interface{}{"private.logs", "Indexes a, b are identical."})
+			default:
+				schema := []string{"private", "public"}
+				if r.Exposed {
+					schema = []string{"public"}
+				}
+				conn.Query(sql, schema).Reply("SELECT 0")
+			}
+		}
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectByConfig(ctx, dbConfig, conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		result, err := AdviseDatabase(ctx, mock, []string{"private", "public"}, []string{"public", "storage"}, Rules)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, []Result{{
+			Object: "public.todos",
+			Issues: []Issue{{
+				Level:   "error",
+				Message: "Row level security is disabled.",
+				Hint:    Rules[0].Remediation,
+				Rule:    "rls_disabled_in_public",
+			}},
+		}, {
+			Object: "private.logs",
+			Issues: []Issue{{
+				Level:   "warning",
+				Message: "Indexes a, b are identical.",
+				Hint:    Rules[4].Remediation,
+				Rule:    "duplicate_index",
+			}},
+		}}, result)
+	})
+
+	t.Run("checks views on Postgres 15", func(t *testing.T) {
+		rule := Rules[5]
+		sql, err := rule.Query()
+		require.NoError(t, err)
+		// Setup mock postgres
+		conn := pgtest.NewWithStatus(map[string]string{
+			"server_version":              "15.1 (Debian 15.1-1.pgdg110+1)",
+			"standard_conforming_strings": "on",
+		})
+		defer conn.Close(t)
+		conn.Query(sql, []string{"public"}).
+			Reply("SELECT 1", []interface{}{"public.todos_view", "View bypasses row level security of the underlying tables."})
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectByConfig(ctx, dbConfig, conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		result, err := AdviseDatabase(ctx, mock, []string{"public"}, []string{"public"}, []Rule{rule})
+		// Check error
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.Equal(t, "security_definer_view", result[0].Issues[0].Rule)
+	})
+
+	t.Run("skips query when no rules are selected", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectByConfig(ctx, dbConfig, conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		result, err := AdviseDatabase(ctx, mock, nil, []string{"public"}, nil)
+		// Check error
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("throws error on query failure", func(t *testing.T) {
+		sql, err := Rules[0].Query()
+		require.NoError(t, err)
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(sql, []string{"public"}).
+			ReplyError(pgerrcode.UndefinedTable, `relation "pg_class" does not exist`)
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectByConfig(ctx, dbConfig, conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		_, err = AdviseDatabase(ctx, mock, []string{"public"}, []string{"public"}, Rules[:1])
+		// Check error
+		assert.ErrorContains(t, err, `failed to parse rows: ERROR: relation "pg_class" does not exist (SQLSTATE 42P01)`)
+	})
+}
+
+func TestSelectRules(t *testing.T) {
+	t.Run("selects no rules by default", func(t *testing.T) {
+		rules, err := SelectRules(nil, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, rules)
+	})
+
+	t.Run("selects all rules", func(t *testing.T) {
+		rules, err := SelectRules([]string{AllRules}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, Rules, rules)
+	})
+
+	t.Run("selects included rules", func(t *testing.T) {
+		rules, err := SelectRules([]string{"duplicate_index", "rls_disabled_in_public"}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []Rule{Rules[0], Rules[4]}, rules)
+	})
+
+	t.Run("skips excluded rules", func(t *testing.T) {
+		rules, err := SelectRules([]string{AllRules}, []string{"auth_rls_initplan"})
+		assert.NoError(t, err)
+		assert.Equal(t, Rules[:len(Rules)-1], rules)
+	})
+
+	t.Run("throws error on exclude without include", func(t *testing.T) {
+		_, err := SelectRules(nil, []string{"auth_rls_initplan"})
+		assert.ErrorContains(t, err, "requires the --rule flag")
+	})
+
+	t.Run("throws error on unknown rule", func(t *testing.T) {
+		_, err := SelectRules([]string{AllRules}, []string{"missing"})
+		assert.ErrorContains(t, err, "Unknown lint rule: missing")
+	})
+}
+
 func TestPrintResult(t *testing.T) {
 	result := []Result{{
 		Function: "public.f1",
@@ -258,4 +411,153 @@ func TestPrintResult(t *testing.T) {
 			Issues:   []Issue{result[0].Issues[1]},
 		}}, actual)
 	})
+
+	t.Run("prints sarif log", func(t *testing.T) {
+		// Run test
+		var out bytes.Buffer
+		assert.NoError(t, printResult(result, toEnum("warning"), OutputSarif, &out))
+		// Validate output
+		var actual sarifLog
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &actual))
+		assert.Equal(t, sarifVersion, actual.Version)
+		require.Len(t, actual.Runs, 1)
+		assert.Equal(t, []sarifRule{{Id: defaultRule}}, actual.Runs[0].Tool.Driver.Rules)
+		require.Len(t, actual.Runs[0].Results, 3)
+		assert.Equal(t, "error", actual.Runs[0].Results[1].Level)
+		assert.Equal(t, "private.f2", actual.Runs[0].Results[2].Locations[0].LogicalLocations[0].FullyQualifiedName)
+	})
+
+	t.Run("prints junit report", func(t *testing.T) {
+		// Run test
+		var out bytes.Buffer
+		assert.NoError(t, printResult(result, toEnum("error"), OutputJunit, &out))
+		// Validate output
+		assert.Equal(t, xml.Header+`<testsuites>
+  <testsuite name="supabase-db-lint" tests="1" failures="1">
+    <testcase name="public.f1" classname="plpgsql_check">
+      <failure type="error" message="test 1b"></failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, out.String())
+	})
+
+	t.Run("prints github annotations", func(t *testing.T) {
+		// Run test
+		var out bytes.Buffer
+		assert.NoError(t, printResult(result, toEnum("warning"), OutputGithub, &out))
+		// Validate output
+		assert.Equal(t, `::warning title=public.f1::test 1a
+::error title=public.f1::test 1b
+::warning title=private.f2::test 2
+`, out.String())
+	})
+
+	t.Run("counts issues above level", func(t *testing.T) {
+		assert.Equal(t, 3, countIssues(result, toEnum("warning")))
+		assert.Equal(t, 1, countIssues(result, toEnum("error")))
+	})
+}
+
+func TestReportResult(t *testing.T) {
+	result := []Result{{
+		Function: "public.f1",
+		Issues: []Issue{{
+			Level:   "warning",
+			Message: `never read variable "entity"`,
+		}},
+	}}
+
+	t.Run("ignores issues hidden by level", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, reportResult(result, "error", "warning", utils.OutputJson, &out))
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("fails on visible issues", func(t *testing.T) {
+		var out bytes.Buffer
+		err := reportResult(result, "warning", "warning", utils.OutputJson, &out)
+		assert.ErrorContains(t, err, "Found 1 issues at warning level or above.")
+		assert.Contains(t, out.String(), `never read variable \"entity\"`)
+	})
+
+	t.Run("prints empty report", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, reportResult(nil, "warning", "", OutputJunit, &out))
+		assert.Contains(t, out.String(), `tests="0"`)
+	})
+}
+
+func TestMapSources(t *testing.T) {
+	t.Run("maps results to latest migration", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		first := filepath.Join(utils.MigrationsDir, "20240101000000_init.sql")
+		require.NoError(t, afero.WriteFile(fsys, first, []byte(`create table todos (id bigint);
+
+create function f1() returns void as $$
+begin
+end;
+$$ language plpgsql;
+`), 0644))
+		second := filepath.Join(utils.MigrationsDir, "20240102000000_update.sql")
+		require.NoError(t, afero.WriteFile(fsys, second, []byte(`-- Replaces f1
+CREATE OR REPLACE FUNCTION "public"."f1"()
+  RETURNS void
+  LANGUAGE plpgsql
+AS $function$
+begin
+  raise notice '%', r.c;
+end;
+$function$;
+
+CREATE VIEW "Private".report AS SELECT 1;
+`), 0644))
+		result := []Result{{
+			Function: "public.f1",
+			Issues: []Issue{{
+				Level:     "error",
+				Statement: &Statement{LineNumber: "3", Text: "RAISE"},
+			}},
+		}, {
+			Object: "public.todos",
+			Issues: []Issue{{Level: "error", Rule: "rls_disabled_in_public"}},
+		}, {
+			Function: `"Private".report`,
+		}, {
+			Function: "public.missing(integer)",
+		}}
+		// Run test
+		mapped, err := MapSources(result, fsys)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, second, mapped[0].File)
+		assert.Equal(t, 2, mapped[0].Line)
+		assert.Equal(t, 7, mapped[0].issueLine(mapped[0].Issues[0]))
+		assert.Equal(t, first, mapped[1].File)
+		assert.Equal(t, 1, mapped[1].Line)
+		assert.Equal(t, 1, mapped[1].issueLine(mapped[1].Issues[0]))
+		assert.Equal(t, second, mapped[2].File)
+		assert.Equal(t, 11, mapped[2].Line)
+		assert.Empty(t, mapped[3].File)
+	})
+
+	t.Run("annotates github output with file", func(t *testing.T) {
+		result := []Result{{
+			Function: "public.f1",
+			File:     "supabase/migrations/0_init.sql",
+			Line:     2,
+			Issues: []Issue{{
+				Level:     "error",
+				Message:   "test",
+				Statement: &Statement{LineNumber: "3"},
+			}},
+			bodyLine: 5,
+		}}
+		// Run test
+		var out bytes.Buffer
+		assert.NoError(t, printResult(result, toEnum("warning"), OutputGithub, &out))
+		// Validate output
+		assert.Equal(t, "::error file=supabase/migrations/0_init.sql,line=7,title=public.f1 line 3::test\n", out.String())
+	})
 }
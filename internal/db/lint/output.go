@@ -0,0 +1,299 @@
+package lint
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const (
+	OutputSarif  = "sarif"
+	OutputJunit  = "junit"
+	OutputGithub = "github"
+
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "supabase-db-lint"
+	defaultRule  = "plpgsql_check"
+)
+
+var AllowedOutputs = []string{
+	utils.OutputJson,
+	OutputSarif,
+	OutputJunit,
+	OutputGithub,
+}
+
+func printResult(result []Result, minLevel LintLevel, format string, stdout io.Writer) error {
+	switch format {
+	case OutputSarif:
+		return printResultSarif(filterResult(result, minLevel), stdout)
+	case OutputJunit:
+		return printResultJunit(filterResult(result, minLevel), stdout)
+	case OutputGithub:
+		return printResultGithub(filterResult(result, minLevel), stdout)
+	}
+	return printResultJSON(result, minLevel, stdout)
+}
+
+// Counts the issues at or above the given level.
+func countIssues(result []Result, minLevel LintLevel) (count int) {
+	for _, r := range filterResult(result, minLevel) {
+		count += len(r.Issues)
+	}
+	return count
+}
+
+func ruleId(issue Issue) string {
+	if len(issue.Rule) > 0 {
+		return issue.Rule
+	}
+	if len(issue.SQLState) > 0 {
+		return issue.SQLState
+	}
+	return defaultRule
+}
+
+func (issue Issue) lineNumber() string {
+	if issue.Statement != nil {
+		return issue.Statement.LineNumber
+	}
+	return ""
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationUri string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	Id   string        `json:"id"`
+	Help *sarifMessage `json:"help,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleId     string          `json:"ruleId"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	Uri string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+func printResultSarif(result []Result, stdout io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           toolName,
+			InformationUri: "https://supabase.com/docs/reference/cli/supabase-db-lint",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	rules := map[string]struct{}{}
+	for _, r := range result {
+		for _, issue := range r.Issues {
+			id := ruleId(issue)
+			if _, ok := rules[id]; !ok {
+				rules[id] = struct{}{}
+				rule := sarifRule{Id: id}
+				if len(issue.Rule) > 0 {
+					rule.Help = &sarifMessage{Text: issue.Hint}
+				}
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+			}
+			level := "warning"
+			if toEnum(issue.Level) == toEnum("error") {
+				level = "error"
+			}
+			properties := map[string]any{}
+			if line := issue.lineNumber(); len(line) > 0 {
+				properties["lineNumber"] = line
+			}
+			if len(issue.Hint) > 0 {
+				properties["hint"] = issue.Hint
+			}
+			if len(issue.Detail) > 0 {
+				properties["detail"] = issue.Detail
+			}
+			location := sarifLocation{LogicalLocations: []sarifLogicalLocation{{
+				FullyQualifiedName: r.Name(),
+				Kind:               r.kind(),
+			}}}
+			if len(r.File) > 0 {
+				location.PhysicalLocation = &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{Uri: filepath.ToSlash(r.File)},
+					Region:           sarifRegion{StartLine: r.issueLine(issue)},
+				}
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleId:     id,
+				Level:      level,
+				Message:    sarifMessage{Text: issue.Message},
+				Locations:  []sarifLocation{location},
+				Properties: properties,
+			})
+		}
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}); err != nil {
+		return errors.Errorf("failed to print result sarif: %w", err)
+	}
+	return nil
+}
+
+// Logical location kind of the result, ie. a table reported by catalog rules.
+func (r Result) kind() string {
+	if len(r.Function) > 0 {
+		return "function"
+	}
+	return "object"
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func printResultJunit(result []Result, stdout io.Writer) error {
+	suite := junitTestSuite{Name: toolName}
+	for _, r := range result {
+		for _, issue := range r.Issues {
+			var detail []string
+			if line := issue.lineNumber(); len(line) > 0 {
+				detail = append(detail, "Line: "+line)
+			}
+			if len(issue.Detail) > 0 {
+				detail = append(detail, "Detail: "+issue.Detail)
+			}
+			if len(issue.Hint) > 0 {
+				detail = append(detail, "Hint: "+issue.Hint)
+			}
+			testCase := junitTestCase{
+				Name:      r.Name(),
+				ClassName: ruleId(issue),
+				Failure: &junitFailure{
+					Type:    issue.Level,
+					Message: issue.Message,
+					Text:    strings.Join(detail, "\n"),
+				},
+			}
+			if len(r.File) > 0 {
+				testCase.File = filepath.ToSlash(r.File)
+				testCase.Line = r.issueLine(issue)
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+	}
+	suite.Tests = len(suite.Cases)
+	suite.Failures = len(suite.Cases)
+	if _, err := io.WriteString(stdout, xml.Header); err != nil {
+		return errors.Errorf("failed to print result junit: %w", err)
+	}
+	enc := xml.NewEncoder(stdout)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return errors.Errorf("failed to print result junit: %w", err)
+	}
+	fmt.Fprintln(stdout)
+	return nil
+}
+
+// Prints workflow commands that annotate pull requests on GitHub Actions.
+func printResultGithub(result []Result, stdout io.Writer) error {
+	for _, r := range result {
+		for _, issue := range r.Issues {
+			command := "warning"
+			if toEnum(issue.Level) == toEnum("error") {
+				command = "error"
+			}
+			title := r.Name()
+			if line := issue.lineNumber(); len(line) > 0 {
+				title += " line " + line
+			}
+			properties := "title=" + escapeProperty(title)
+			if len(r.File) > 0 {
+				properties = fmt.Sprintf("file=%s,line=%d,%s", escapeProperty(filepath.ToSlash(r.File)), r.issueLine(issue), properties)
+			}
+			fmt.Fprintf(stdout, "::%s %s::%s\n", command, properties, escapeData(issue.Message))
+		}
+	}
+	return nil
+}
+
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,199 @@
+package lint
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/supabase/cli/internal/db/reset"
+	"github.com/supabase/cli/internal/utils"
+)
+
+var (
+	//go:embed templates/rules/*.sql
+	ruleQueries embed.FS
+
+	// Catalog based checks, each loaded from templates/rules/<id>.sql. The query
+	// receives an array of schemas and returns pairs of object name and message.
+	Rules = []Rule{{
+		Id:          "rls_disabled_in_public",
+		Level:       "error",
+		Remediation: "Enable row level security on the table and add policies for each role that needs access.",
+		Exposed:     true,
+	}, {
+		Id:          "rls_enabled_no_policy",
+		Level:       "warning",
+		Remediation: "Create a policy for each role and command that should be allowed, otherwise only the table owner can access rows.",
+	}, {
+		Id:          "function_search_path_mutable",
+		Level:       "warning",
+		Remediation: "Set a fixed search path on the function, ie. ALTER FUNCTION ... SET search_path = ''.",
+	}, {
+		Id:          "unindexed_foreign_keys",
+		Level:       "warning",
+		Remediation: "Create an index on the foreign key columns to speed up joins and cascading deletes.",
+	}, {
+		Id:          "duplicate_index",
+		Level:       "warning",
+		Remediation: "Drop all but one of the identical indexes to reduce write overhead.",
+	}, {
+		Id:          "security_definer_view",
+		Level:       "error",
+		Remediation: "Recreate the view WITH (security_invoker = on) so that policies are checked against the querying role.",
+		Exposed:     true,
+		// Views always bypass row level security before security_invoker was added
+		MinVersion: 15,
+	}, {
+		Id:          "auth_rls_initplan",
+		Level:       "warning",
+		Remediation: "Wrap calls to auth functions in a sub-select, ie. (SELECT auth.uid()), so they are evaluated once per query.",
+	}}
+)
+
+type Rule struct {
+	Id          string
+	Level       string
+	Remediation string
+	// Only applies to schemas exposed through the Data API
+	Exposed bool
+	// Minimum major version of Postgres to check against
+	MinVersion uint64
+}
+
+const AllRules = "all"
+
+// SelectRules returns the built-in rules to check, either those included by id
+// or all rules except those excluded. Rules are opt-in so that linting without
+// the --rule flag only type checks functions.
+func SelectRules(include, exclude []string) ([]Rule, error) {
+	for _, id := range include {
+		if id != AllRules && !utils.SliceContains(ruleIds(), id) {
+			return nil, errors.Errorf("Unknown lint rule: %s (must be one of %s)", id, strings.Join(append(ruleIds(), AllRules), ", "))
+		}
+	}
+	for _, id := range exclude {
+		if !utils.SliceContains(ruleIds(), id) {
+			return nil, errors.Errorf("Unknown lint rule: %s (must be one of %s)", id, strings.Join(ruleIds(), ", "))
+		}
+	}
+	if len(include) == 0 {
+		if len(exclude) > 0 {
+			return nil, errors.New("Excluding lint rules requires the --rule flag, ie. --rule all")
+		}
+		return nil, nil
+	}
+	var result []Rule
+	for _, r := range Rules {
+		if !utils.SliceContains(include, AllRules) && !utils.SliceContains(include, r.Id) {
+			continue
+		}
+		if utils.SliceContains(exclude, r.Id) {
+			continue
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+func ruleIds() []string {
+	ids := make([]string, len(Rules))
+	for i, r := range Rules {
+		ids[i] = r.Id
+	}
+	return ids
+}
+
+func (r Rule) Query() (string, error) {
+	data, err := ruleQueries.ReadFile(path.Join("templates", "rules", r.Id+".sql"))
+	if err != nil {
+		return "", errors.Errorf("failed to read rule %s: %w", r.Id, err)
+	}
+	return string(data), nil
+}
+
+// AdviseDatabase runs security and performance rules against the system catalog.
+func AdviseDatabase(ctx context.Context, conn *pgx.Conn, schema, exposed []string, rules []Rule) ([]Result, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	if len(schema) == 0 {
+		var err error
+		if schema, err = reset.ListSchemas(ctx, conn, utils.InternalSchemas...); err != nil {
+			return nil, err
+		}
+	}
+	var included []string
+	for _, s := range exposed {
+		if utils.SliceContains(schema, s) {
+			included = append(included, s)
+		}
+	}
+	version := serverMajorVersion(conn)
+	var result []Result
+	for _, r := range rules {
+		if version < r.MinVersion {
+			fmt.Fprintf(os.Stderr, "Skipping rule %s: requires Postgres %d or above\n", r.Id, r.MinVersion)
+			continue
+		}
+		fmt.Fprintln(os.Stderr, "Checking rule:", r.Id)
+		sql, err := r.Query()
+		if err != nil {
+			return nil, err
+		}
+		target := schema
+		if r.Exposed {
+			target = included
+		}
+		rows, err := conn.Query(ctx, sql, target)
+		if err != nil {
+			return nil, errors.Errorf("failed to check rule %s: %w", r.Id, err)
+		}
+		issues, err := r.collectIssues(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, issues...)
+	}
+	return result, nil
+}
+
+func serverMajorVersion(conn *pgx.Conn) uint64 {
+	serverVersion := conn.PgConn().ParameterStatus("server_version")
+	// Version strings may have suffixes, ie. 16beta1 or 14.3 (Debian 14.3-1.pgdg110+1)
+	if end := strings.IndexFunc(serverVersion, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+		serverVersion = serverVersion[:end]
+	}
+	// Treat unknown version as the oldest supported
+	major, _ := strconv.ParseUint(serverVersion, 10, 64)
+	return major
+}
+
+func (r Rule) collectIssues(rows pgx.Rows) ([]Result, error) {
+	defer rows.Close()
+	var result []Result
+	for rows.Next() {
+		var name, message string
+		if err := rows.Scan(&name, &message); err != nil {
+			return nil, errors.Errorf("failed to scan rows: %w", err)
+		}
+		result = append(result, Result{
+			Object: name,
+			Issues: []Issue{{
+				Level:   r.Level,
+				Message: message,
+				Hint:    r.Remediation,
+				Rule:    r.Id,
+			}},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Errorf("failed to parse rows: %w", err)
+	}
+	return result, nil
+}
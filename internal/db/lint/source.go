@@ -0,0 +1,113 @@
+package lint
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/migration/list"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const identPattern = `(?:"(?:[^"]|"")+"|[\w$]+)`
+
+var (
+	// Statements that create or replace an object, capturing its qualified name.
+	definitionPattern = regexp.MustCompile(`(?im)^[ \t]*create\s+(?:or\s+replace\s+)?(?:(?:unlogged|materialized|recursive)\s+)?(table|view|function|procedure)\s+(?:if\s+not\s+exists\s+)?(` + identPattern + `(?:\s*\.\s*` + identPattern + `)?)`)
+	identRegexp       = regexp.MustCompile(identPattern)
+	dollarQuote       = regexp.MustCompile(`\$(?:[A-Za-z_]\w*)?\$`)
+)
+
+type SourceLocation struct {
+	File string
+	Line int
+	// Line of the opening dollar quote of a function body
+	BodyLine int
+}
+
+// MapSources annotates each result with the most recent local migration that
+// created or replaced the linted object.
+func MapSources(result []Result, fsys afero.Fs) ([]Result, error) {
+	sources, err := LoadSources(fsys)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range result {
+		if loc, ok := sources[NormalizeName(r.Name())]; ok {
+			result[i].File = loc.File
+			result[i].Line = loc.Line
+			result[i].bodyLine = loc.BodyLine
+		}
+	}
+	return result, nil
+}
+
+// LoadSources finds the location of tables, views and functions defined in
+// local migrations, keyed by their normalised names.
+func LoadSources(fsys afero.Fs) (map[string]SourceLocation, error) {
+	migrations, err := list.LoadLocalMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	sources := map[string]SourceLocation{}
+	for _, name := range migrations {
+		path := filepath.Join(utils.MigrationsDir, name)
+		contents, err := afero.ReadFile(fsys, path)
+		if err != nil {
+			return nil, errors.Errorf("failed to read migration: %w", err)
+		}
+		// Later migrations take precedence
+		for key, loc := range findDefinitions(string(contents)) {
+			loc.File = path
+			sources[key] = loc
+		}
+	}
+	return sources, nil
+}
+
+func findDefinitions(sql string) map[string]SourceLocation {
+	defs := map[string]SourceLocation{}
+	for _, m := range definitionPattern.FindAllStringSubmatchIndex(sql, -1) {
+		loc := SourceLocation{Line: 1 + strings.Count(sql[:m[0]], "\n")}
+		kind := strings.ToLower(sql[m[2]:m[3]])
+		if kind == "function" || kind == "procedure" {
+			// Body must be quoted before the end of statement
+			rest := sql[m[5]:]
+			if body := dollarQuote.FindStringIndex(rest); body != nil && !strings.Contains(rest[:body[0]], ";") {
+				loc.BodyLine = 1 + strings.Count(sql[:m[5]+body[0]], "\n")
+			}
+		}
+		defs[NormalizeName(sql[m[4]:m[5]])] = loc
+	}
+	return defs
+}
+
+// NormalizeName converts a possibly quoted and qualified name to schema.name, ignoring function arguments.
+func NormalizeName(name string) string {
+	var parts []string
+	for _, ident := range identRegexp.FindAllString(strings.SplitN(name, "(", 2)[0], 2) {
+		if strings.HasPrefix(ident, `"`) {
+			ident = strings.ReplaceAll(ident[1:len(ident)-1], `""`, `"`)
+		} else {
+			ident = strings.ToLower(ident)
+		}
+		parts = append(parts, ident)
+	}
+	if len(parts) == 1 {
+		parts = append([]string{"public"}, parts...)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Returns the line in the migration file where an issue was found.
+func (r Result) issueLine(issue Issue) int {
+	if r.bodyLine > 0 && issue.Statement != nil {
+		if n, err := strconv.Atoi(issue.Statement.LineNumber); err == nil && n > 0 {
+			return r.bodyLine + n - 1
+		}
+	}
+	return r.Line
+}
@@ -0,0 +1,6 @@
+-- Policies that call auth functions once per row instead of once per query
+SELECT format('%I.%I', p.schemaname, p.tablename), format('Policy %I re-evaluates auth functions for each row.', p.policyname)
+FROM pg_catalog.pg_policies p
+WHERE p.schemaname = ANY($1::text[])
+  AND regexp_replace(coalesce(p.qual, '') || ' ' || coalesce(p.with_check, ''), 'SELECT\s+auth\.(uid|jwt|role)\(\)', '', 'gi') ~ 'auth\.(uid|jwt|role)\(\)'
+ORDER BY 1, p.policyname;
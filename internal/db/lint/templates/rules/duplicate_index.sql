@@ -0,0 +1,11 @@
+-- Indexes on the same table with identical definitions
+SELECT format('%I.%I', n.nspname, c.relname), format('Indexes %s are identical.', string_agg(quote_ident(ic.relname), ', ' ORDER BY ic.relname))
+FROM pg_catalog.pg_index i
+JOIN pg_catalog.pg_class ic ON ic.oid = i.indexrelid
+JOIN pg_catalog.pg_class c ON c.oid = i.indrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = ANY($1::text[])
+GROUP BY n.nspname, c.relname, i.indkey::text, i.indclass::text, i.indcollation::text,
+  coalesce(pg_catalog.pg_get_expr(i.indexprs, i.indrelid), ''), coalesce(pg_catalog.pg_get_expr(i.indpred, i.indrelid), '')
+HAVING count(*) > 1
+ORDER BY 1, 2;
@@ -0,0 +1,6 @@
+-- Tables exposed through the Data API without row level security
+SELECT format('%I.%I', n.nspname, c.relname), 'Row level security is disabled on a table exposed through the Data API.'
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p') AND NOT c.relrowsecurity AND n.nspname = ANY($1::text[])
+ORDER BY 1;
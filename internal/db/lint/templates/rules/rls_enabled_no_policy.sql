@@ -0,0 +1,7 @@
+-- Tables with row level security enabled that deny all access
+SELECT format('%I.%I', n.nspname, c.relname), 'Row level security is enabled but no policies exist.'
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p') AND c.relrowsecurity AND n.nspname = ANY($1::text[])
+  AND NOT EXISTS (SELECT 1 FROM pg_catalog.pg_policy p WHERE p.polrelid = c.oid)
+ORDER BY 1;
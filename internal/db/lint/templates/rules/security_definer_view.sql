@@ -0,0 +1,8 @@
+-- Views exposed through the Data API that run with the privileges of their owner
+SELECT format('%I.%I', n.nspname, c.relname), 'View bypasses row level security of the underlying tables.'
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind = 'v' AND n.nspname = ANY($1::text[])
+  AND NOT EXISTS (SELECT 1 FROM unnest(coalesce(c.reloptions, '{}')) opt WHERE lower(opt) IN ('security_invoker=true', 'security_invoker=on', 'security_invoker=1'))
+  AND NOT EXISTS (SELECT 1 FROM pg_catalog.pg_depend d WHERE d.objid = c.oid AND d.deptype = 'e')
+ORDER BY 1;
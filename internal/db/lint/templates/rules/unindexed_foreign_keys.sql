@@ -0,0 +1,13 @@
+-- Foreign keys whose columns are not the leading columns of any index
+SELECT format('%I.%I', n.nspname, c.relname), format('Foreign key %I has no covering index.', con.conname)
+FROM pg_catalog.pg_constraint con
+JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE con.contype = 'f' AND n.nspname = ANY($1::text[])
+  AND NOT EXISTS (
+    SELECT 1 FROM pg_catalog.pg_index i
+    WHERE i.indrelid = con.conrelid
+      AND (string_to_array(i.indkey::text, ' ')::int2[])[1:array_length(con.conkey, 1)] @> con.conkey
+      AND (string_to_array(i.indkey::text, ' ')::int2[])[1:array_length(con.conkey, 1)] <@ con.conkey
+  )
+ORDER BY 1, con.conname;
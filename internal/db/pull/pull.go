@@ -1,6 +1,7 @@
 package pull
 
 import (
+	"bytes"
 	"context"
 	_ "embed"
 	"fmt"
@@ -25,14 +26,14 @@ var (
 	errInSync        = errors.New("no schema changes found")
 	errConflict      = errors.Errorf("The remote database's migration history does not match local files in %s directory.", utils.MigrationsDir)
 	suggestExtraPull = fmt.Sprintf(
-		"The %s and %s schemas are excluded. Run %s again to diff them.",
+		"The %s and %s schemas are excluded. Run %s again to diff your policies, triggers and functions in them.",
 		utils.Bold("auth"),
 		utils.Bold("storage"),
-		utils.Aqua("supabase db pull --schema auth,storage"),
+		utils.Aqua("supabase db pull --include-managed"),
 	)
 )
 
-func Run(ctx context.Context, schema []string, config pgconn.Config, name string, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+func Run(ctx context.Context, schema []string, config pgconn.Config, name string, includeManaged bool, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
 	// 1. Sanity checks.
 	if err := utils.AssertDockerIsRunning(ctx); err != nil {
 		return err
@@ -50,7 +51,7 @@ func Run(ctx context.Context, schema []string, config pgconn.Config, name string
 	timestamp := utils.GetCurrentTimestamp()
 	path := new.GetMigrationPath(timestamp, name)
 	if err := utils.RunProgram(ctx, func(p utils.Program, ctx context.Context) error {
-		return run(p, ctx, schema, path, conn, fsys)
+		return run(p, ctx, schema, path, includeManaged, conn, fsys)
 	}); err != nil {
 		return err
 	}
@@ -62,16 +63,19 @@ func Run(ctx context.Context, schema []string, config pgconn.Config, name string
 	return nil
 }
 
-func run(p utils.Program, ctx context.Context, schema []string, path string, conn *pgx.Conn, fsys afero.Fs) error {
+func run(p utils.Program, ctx context.Context, schema []string, path string, includeManaged bool, conn *pgx.Conn, fsys afero.Fs) error {
 	config := conn.Config().Config
 	defaultSchema := len(schema) == 0
 	// 1. Assert `supabase/migrations` and `schema_migrations` are in sync.
 	if err := assertRemoteInSync(ctx, conn, fsys); errors.Is(err, errMissing) {
-		if !defaultSchema {
+		if !defaultSchema && !includeManaged {
 			utils.CmdSuggestion = suggestExtraPull
 		}
 		// Not passing down schemas to avoid pulling in managed schemas
-		return dumpRemoteSchema(p, ctx, path, config, fsys)
+		if err := dumpRemoteSchema(p, ctx, path, config, fsys); err != nil || !includeManaged {
+			return err
+		}
+		return dumpManagedObjects(p, ctx, path, conn, fsys)
 	} else if err != nil {
 		return err
 	}
@@ -83,8 +87,12 @@ func run(p utils.Program, ctx context.Context, schema []string, path string, con
 			return err
 		}
 	}
-	err := diffRemoteSchema(p, ctx, schema, path, config, fsys)
-	if defaultSchema && (err == nil || errors.Is(errInSync, err)) {
+	differ := diff.DiffSchemaMigra
+	if includeManaged {
+		differ = diff.WithManagedObjects(differ)
+	}
+	err := diffRemoteSchema(p, ctx, schema, path, config, differ, fsys)
+	if defaultSchema && !includeManaged && (err == nil || errors.Is(errInSync, err)) {
 		utils.CmdSuggestion = suggestExtraPull
 	}
 	return err
@@ -104,10 +112,36 @@ func dumpRemoteSchema(p utils.Program, ctx context.Context, path string, config
 	return dump.DumpSchema(ctx, config, nil, false, false, f)
 }
 
-func diffRemoteSchema(p utils.Program, ctx context.Context, schema []string, path string, config pgconn.Config, fsys afero.Fs) error {
+// Appends user defined objects in managed schemas to the initial migration, the same as diffing them.
+func dumpManagedObjects(p utils.Program, ctx context.Context, path string, conn *pgx.Conn, fsys afero.Fs) error {
+	p.Send(utils.StatusMsg("Dumping managed schemas from remote database..."))
+	var managed bytes.Buffer
+	if err := dump.DumpSchema(ctx, conn.Config().Config, diff.ManagedSchemas, false, false, &managed); err != nil {
+		return err
+	}
+	owned, err := diff.ListOwnedFunctions(ctx, conn)
+	if err != nil {
+		return err
+	}
+	filtered, err := diff.FilterManagedObjects(managed.String(), owned)
+	if err != nil || len(filtered) == 0 {
+		return err
+	}
+	f, err := fsys.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Errorf("failed to open dump file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("\n" + filtered); err != nil {
+		return errors.Errorf("failed to write dump file: %w", err)
+	}
+	return nil
+}
+
+func diffRemoteSchema(p utils.Program, ctx context.Context, schema []string, path string, config pgconn.Config, differ diff.DiffFunc, fsys afero.Fs) error {
 	w := utils.StatusWriter{Program: p}
 	// Diff remote db (source) & shadow db (target) and write it as a new migration.
-	output, err := diff.DiffDatabase(ctx, schema, config, w, fsys, diff.DiffSchemaMigra)
+	output, err := diff.DiffDatabase(ctx, schema, config, w, fsys, differ)
 	if err != nil {
 		return err
 	}
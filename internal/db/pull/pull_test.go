@@ -13,6 +13,7 @@ import (
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/db/diff"
 	"github.com/supabase/cli/internal/db/reset"
 	"github.com/supabase/cli/internal/migration/list"
 	"github.com/supabase/cli/internal/testing/apitest"
@@ -71,7 +72,7 @@ func TestPullCommand(t *testing.T) {
 			Get("/_ping").
 			ReplyError(errors.New("network error"))
 		// Run test
-		err := Run(context.Background(), nil, pgconn.Config{}, "", fsys)
+		err := Run(context.Background(), nil, pgconn.Config{}, "", false, fsys)
 		// Check error
 		assert.ErrorContains(t, err, "network error")
 		assert.Empty(t, apitest.ListUnmatchedRequests())
@@ -94,7 +95,7 @@ func TestPullCommand(t *testing.T) {
 			SetHeader("API-Version", utils.Docker.ClientVersion()).
 			SetHeader("OSType", "linux")
 		// Run test
-		err := Run(context.Background(), nil, pgconn.Config{}, "", fsys)
+		err := Run(context.Background(), nil, pgconn.Config{}, "", false, fsys)
 		// Check error
 		assert.ErrorIs(t, err, os.ErrNotExist)
 		assert.Empty(t, apitest.ListUnmatchedRequests())
@@ -118,7 +119,7 @@ func TestPullCommand(t *testing.T) {
 			SetHeader("API-Version", utils.Docker.ClientVersion()).
 			SetHeader("OSType", "linux")
 		// Run test
-		err := Run(context.Background(), nil, pgconn.Config{}, "", fsys)
+		err := Run(context.Background(), nil, pgconn.Config{}, "", false, fsys)
 		// Check error
 		assert.ErrorContains(t, err, "invalid port (outside range)")
 		assert.Empty(t, apitest.ListUnmatchedRequests())
@@ -147,7 +148,7 @@ func TestPullCommand(t *testing.T) {
 		conn.Query(list.LIST_MIGRATION_VERSION).
 			ReplyError(pgerrcode.InvalidCatalogName, `database "postgres" does not exist`)
 		// Run test
-		err := Run(context.Background(), nil, dbConfig, "", fsys, conn.Intercept)
+		err := Run(context.Background(), nil, dbConfig, "", false, fsys, conn.Intercept)
 		// Check error
 		assert.ErrorContains(t, err, `ERROR: database "postgres" does not exist (SQLSTATE 3D000)`)
 		assert.Empty(t, apitest.ListUnmatchedRequests())
@@ -175,7 +176,7 @@ func TestPullSchema(t *testing.T) {
 		defer mock.Close(ctx)
 		// Run test
 		err = utils.RunProgram(context.Background(), func(p utils.Program, ctx context.Context) error {
-			return run(p, ctx, nil, "0_test.sql", mock, fsys)
+			return run(p, ctx, nil, "0_test.sql", false, mock, fsys)
 		})
 		// Check error
 		assert.NoError(t, err)
@@ -185,6 +186,47 @@ func TestPullSchema(t *testing.T) {
 		assert.Equal(t, []byte("test"), contents)
 	})
 
+	t.Run("dumps managed objects without migration history", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		// Setup mock docker
+		require.NoError(t, apitest.MockDocker(utils.Docker))
+		defer gock.OffAll()
+		apitest.MockDockerStart(utils.Docker, utils.GetRegistryImageUrl(utils.Pg15Image), "test-db")
+		require.NoError(t, apitest.MockDockerLogs(utils.Docker, "test-db", "create table public.profiles ();\n"))
+		apitest.MockDockerStart(utils.Docker, utils.GetRegistryImageUrl(utils.Pg15Image), "test-managed")
+		require.NoError(t, apitest.MockDockerLogs(utils.Docker, "test-managed", `CREATE TABLE "auth"."users" ();
+CREATE POLICY "Public access" ON "storage"."objects" FOR SELECT USING (true);
+GRANT ALL ON TABLE "auth"."users" TO "anon";
+`))
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(list.LIST_MIGRATION_VERSION).
+			Reply("SELECT 0").
+			Query(diff.LIST_OWNED_FUNCTIONS, diff.ManagedSchemas, []string{"supabase_admin", "supabase_auth_admin", "supabase_storage_admin"}).
+			Reply("SELECT 0")
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectByConfig(ctx, dbConfig, conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		err = utils.RunProgram(context.Background(), func(p utils.Program, ctx context.Context) error {
+			return run(p, ctx, nil, "0_test.sql", true, mock, fsys)
+		})
+		// Check error
+		assert.NoError(t, err)
+		assert.Empty(t, apitest.ListUnmatchedRequests())
+		contents, err := afero.ReadFile(fsys, "0_test.sql")
+		assert.NoError(t, err)
+		assert.Equal(t, `create table public.profiles ();
+
+CREATE POLICY "Public access" ON "storage"."objects" FOR SELECT USING (true);
+
+`, string(contents))
+	})
+
 	t.Run("throws error on load user schema failure", func(t *testing.T) {
 		// Setup in-memory fs
 		fsys := afero.NewMemMapFs()
@@ -204,7 +246,7 @@ func TestPullSchema(t *testing.T) {
 		defer mock.Close(ctx)
 		// Run test
 		err = utils.RunProgram(context.Background(), func(p utils.Program, ctx context.Context) error {
-			return run(p, ctx, nil, "", mock, fsys)
+			return run(p, ctx, nil, "", false, mock, fsys)
 		})
 		// Check error
 		assert.ErrorContains(t, err, `ERROR: relation "test" already exists (SQLSTATE 42P07)`)
@@ -233,7 +275,7 @@ func TestPullSchema(t *testing.T) {
 		defer mock.Close(ctx)
 		// Run test
 		err = utils.RunProgram(context.Background(), func(p utils.Program, ctx context.Context) error {
-			return run(p, ctx, []string{"public"}, "", mock, fsys)
+			return run(p, ctx, []string{"public"}, "", false, mock, fsys)
 		})
 		// Check error
 		assert.ErrorContains(t, err, "network error")
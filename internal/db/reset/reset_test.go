@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/supabase/cli/internal/db/start"
+	"github.com/supabase/cli/internal/migration/list"
 	"github.com/supabase/cli/internal/testing/apitest"
 	"github.com/supabase/cli/internal/testing/pgtest"
 	"github.com/supabase/cli/internal/utils"
@@ -386,3 +388,61 @@ func TestResetRemote(t *testing.T) {
 		assert.ErrorContains(t, err, "ERROR: permission denied for relation supabase_migrations (SQLSTATE 42501)")
 	})
 }
+
+func TestPendingChanges(t *testing.T) {
+	utils.Config.Db.Port = 54322
+
+	t.Run("lists new migrations", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		for _, name := range []string{"0_init.sql", "1_new.sql"} {
+			require.NoError(t, afero.WriteFile(fsys, filepath.Join(utils.MigrationsDir, name), []byte{}, 0644))
+		}
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(list.LIST_MIGRATION_VERSION).
+			Reply("SELECT 1", []interface{}{"0"})
+		// Run test
+		pending, err := listPendingChanges(context.Background(), []string{filepath.Join(utils.MigrationsDir, "1_new.sql")}, fsys, conn.Intercept)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1_new.sql"}, pending)
+	})
+
+	t.Run("resets on applied migration change", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		for _, name := range []string{"0_init.sql", "1_new.sql"} {
+			require.NoError(t, afero.WriteFile(fsys, filepath.Join(utils.MigrationsDir, name), []byte{}, 0644))
+		}
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(list.LIST_MIGRATION_VERSION).
+			Reply("SELECT 1", []interface{}{"0"})
+		// Run test
+		pending, err := listPendingChanges(context.Background(), []string{
+			filepath.Join(utils.MigrationsDir, "0_init.sql"),
+			filepath.Join(utils.MigrationsDir, "1_new.sql"),
+		}, fsys, conn.Intercept)
+		// Check error
+		assert.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("resets on seed change", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(list.LIST_MIGRATION_VERSION).
+			Reply("SELECT 0")
+		// Run test
+		pending, err := listPendingChanges(context.Background(), []string{utils.SeedDataPath}, fsys, conn.Intercept)
+		// Check error
+		assert.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+}
@@ -0,0 +1,97 @@
+package reset
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/migration/apply"
+	"github.com/supabase/cli/internal/migration/up"
+	"github.com/supabase/cli/internal/utils"
+)
+
+var WatchPaths = []string{utils.MigrationsDir, utils.SeedDataPath}
+
+// Watch resets the local database on start, and applies changes to migrations or seed file until cancelled.
+func Watch(ctx context.Context, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	if !utils.IsLocalDatabase(config) {
+		return errors.New("--watch flag is only supported on the local database.")
+	}
+	if err := utils.AssertSupabaseDbIsRunning(); err != nil {
+		return err
+	}
+	return utils.RunProgram(ctx, func(p utils.Program, ctx context.Context) error {
+		// Start from a clean database
+		summary, err := ApplyChanges(ctx, nil, fsys, options...)
+		SendWatchStatus(p, summary, err)
+		return utils.WatchFiles(ctx, WatchPaths, func(changed []string) error {
+			p.Send(utils.StatusMsg("Applying changes..."))
+			summary, err := ApplyChanges(ctx, changed, fsys, options...)
+			// Keep watching on failure so that changes can be fixed
+			SendWatchStatus(p, summary, err)
+			return nil
+		})
+	})
+}
+
+// SendWatchStatus shows a compact summary of the last run, keeping errors to a single line.
+func SendWatchStatus(p utils.Program, summary string, err error) {
+	status := utils.Aqua("✔ ") + summary
+	if err != nil {
+		status = utils.Red("✘ ") + strings.SplitN(err.Error(), "\n", 2)[0]
+	}
+	p.Send(utils.StatusMsg(fmt.Sprintf("%s [%s] Watching for changes...", status, time.Now().Format(time.TimeOnly))))
+}
+
+// ApplyChanges updates the local database after local files changed. New migrations
+// are applied incrementally, while any other change resets the database.
+func ApplyChanges(ctx context.Context, changed []string, fsys afero.Fs, options ...func(*pgx.ConnConfig)) (string, error) {
+	if len(changed) > 0 {
+		pending, err := listPendingChanges(ctx, changed, fsys, options...)
+		if err != nil {
+			return "", err
+		}
+		if len(pending) > 0 {
+			conn, err := utils.ConnectLocalPostgres(ctx, pgconn.Config{}, options...)
+			if err != nil {
+				return "", err
+			}
+			defer conn.Close(context.Background())
+			if err := apply.MigrateUp(ctx, conn, pending, fsys); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Applied %d new migrations.", len(pending)), nil
+		}
+	}
+	if err := resetDatabase(ctx, "", fsys, options...); err != nil {
+		return "", err
+	}
+	return "Reset local database.", nil
+}
+
+// Returns pending migrations if they are the only changed files.
+func listPendingChanges(ctx context.Context, changed []string, fsys afero.Fs, options ...func(*pgx.ConnConfig)) ([]string, error) {
+	conn, err := utils.ConnectLocalPostgres(ctx, pgconn.Config{}, options...)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(context.Background())
+	pending, err := up.GetPendingMigrations(ctx, false, conn, fsys)
+	if err != nil {
+		// Out of order migrations require a reset
+		utils.CmdSuggestion = ""
+		return nil, nil
+	}
+	for _, path := range changed {
+		if filepath.Dir(path) != utils.MigrationsDir || !utils.SliceContains(pending, filepath.Base(path)) {
+			return nil, nil
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,220 @@
+package restore
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const (
+	FormatPlain     = "plain"
+	FormatCustom    = "custom"
+	FormatDirectory = "directory"
+	FormatTar       = "tar"
+)
+
+var (
+	//go:embed templates/restore.sh
+	restoreScript string
+
+	errPlainFlags = errors.New("--data-only, --schema, --clean and --jobs flags are only supported for custom, directory and tar archives.")
+	totalPattern  = regexp.MustCompile(`^Restoring (\d+) items\.\.\.$`)
+	itemPattern   = regexp.MustCompile(`^pg_restore: (creating|processing data for|processing item|executing) `)
+)
+
+func Run(ctx context.Context, dumpPath string, config pgconn.Config, schema []string, dataOnly, clean bool, jobs uint, fsys afero.Fs) error {
+	format, err := DetectFormat(dumpPath, fsys)
+	if err != nil {
+		return err
+	}
+	var extraFlags []string
+	if dataOnly {
+		extraFlags = append(extraFlags, "--data-only")
+	}
+	for _, s := range schema {
+		extraFlags = append(extraFlags, "--schema="+s)
+	}
+	if clean {
+		extraFlags = append(extraFlags, "--clean", "--if-exists")
+	}
+	if jobs > 1 {
+		extraFlags = append(extraFlags, fmt.Sprintf("--jobs=%d", jobs))
+	}
+	if format == FormatPlain && len(extraFlags) > 0 {
+		return errors.New(errPlainFlags)
+	}
+	if !utils.IsLocalDatabase(config) {
+		msg := "Do you want to restore " + utils.Bold(dumpPath) + " to the remote database?"
+		if shouldRestore := utils.PromptYesNo(msg, true, os.Stdin); !shouldRestore {
+			return errors.New(context.Canceled)
+		}
+	}
+	// Mount dump file or directory into container
+	srcPath, err := filepath.Abs(dumpPath)
+	if err != nil {
+		return errors.Errorf("failed to resolve absolute path: %w", err)
+	}
+	dstPath := path.Join("/tmp", filepath.Base(srcPath))
+	env := []string{
+		"DUMP_FORMAT=" + format,
+		"DUMP_PATH=" + dstPath,
+	}
+	fmt.Fprintf(os.Stderr, "Restoring %s archive to %s database...\n", format, toDatabaseName(config))
+	if err := utils.RunProgram(ctx, func(p utils.Program, ctx context.Context) error {
+		return restore(ctx, config, env, extraFlags, fmt.Sprintf("%s:%s:ro,z", srcPath, dstPath), p)
+	}); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Finished "+utils.Aqua("supabase db restore")+".")
+	return nil
+}
+
+func toDatabaseName(config pgconn.Config) string {
+	if utils.IsLocalDatabase(config) {
+		return "local"
+	}
+	return "remote"
+}
+
+// DetectFormat guesses the pg_dump output format from file headers, like pg_restore does.
+func DetectFormat(dumpPath string, fsys afero.Fs) (string, error) {
+	info, err := fsys.Stat(dumpPath)
+	if err != nil {
+		return "", errors.Errorf("failed to read dump file: %w", err)
+	}
+	if info.IsDir() {
+		if _, err := fsys.Stat(filepath.Join(dumpPath, "toc.dat")); err != nil {
+			return "", errors.Errorf("failed to read directory archive: %w", err)
+		}
+		return FormatDirectory, nil
+	}
+	f, err := fsys.Open(dumpPath)
+	if err != nil {
+		return "", errors.Errorf("failed to open dump file: %w", err)
+	}
+	defer f.Close()
+	header := make([]byte, 512)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", errors.Errorf("failed to read dump file: %w", err)
+	}
+	header = header[:n]
+	if bytes.HasPrefix(header, []byte("PGDMP")) {
+		return FormatCustom, nil
+	}
+	// Tar archives store a magic string after the first file name
+	if len(header) > 262 && string(header[257:262]) == "ustar" {
+		return FormatTar, nil
+	}
+	return FormatPlain, nil
+}
+
+// Extra flags are passed to pg_restore as positional arguments of the script to avoid word splitting.
+func restore(ctx context.Context, config pgconn.Config, env, extraFlags []string, bind string, p utils.Program) error {
+	// Use custom network when connecting to local database
+	networkID := "host"
+	if utils.IsLocalDatabase(config) {
+		config.Host = utils.DbAliases[0]
+		config.Port = 5432
+		networkID = utils.NetId
+	}
+	env = append(env,
+		"PGHOST="+config.Host,
+		fmt.Sprintf("PGPORT=%d", config.Port),
+		"PGUSER="+config.User,
+		"PGPASSWORD="+config.Password,
+		"PGDATABASE="+config.Database,
+	)
+	// Psql echoes command tags to stdout while pg_restore logs items to stderr
+	stdout := &lineWriter{handle: func(line string) {
+		p.Send(utils.PsqlMsg(&line))
+	}}
+	progress := progressTracker{p: p}
+	stderr := &lineWriter{handle: progress.handleLine}
+	defer p.Send(utils.ProgressMsg(nil))
+	if err := utils.DockerRunOnceWithConfig(
+		ctx,
+		container.Config{
+			Image: utils.Pg15Image,
+			Env:   env,
+			Cmd:   append([]string{"bash", "-c", restoreScript, "--"}, extraFlags...),
+		},
+		container.HostConfig{
+			NetworkMode: container.NetworkMode(networkID),
+			Binds:       []string{bind},
+		},
+		network.NetworkingConfig{},
+		"",
+		stdout,
+		stderr,
+	); err != nil {
+		return errors.Errorf("failed to restore dump: %w\n%s", err, progress.errors.String())
+	}
+	return nil
+}
+
+// Splits written data into lines.
+type lineWriter struct {
+	buf    bytes.Buffer
+	handle func(string)
+}
+
+func (w *lineWriter) Write(data []byte) (int, error) {
+	w.buf.Write(data)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// Keep partial line until the next write
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			break
+		}
+		w.handle(strings.TrimSpace(line))
+	}
+	return len(data), nil
+}
+
+// Tracks pg_restore verbose logs to display progress.
+type progressTracker struct {
+	p      utils.Program
+	total  int
+	done   int
+	errors bytes.Buffer
+}
+
+func (w *progressTracker) handleLine(line string) {
+	if matches := totalPattern.FindStringSubmatch(line); len(matches) > 1 {
+		w.total, _ = strconv.Atoi(matches[1])
+		w.p.Send(utils.StatusMsg(line))
+		return
+	}
+	if !itemPattern.MatchString(line) {
+		// Keep any unexpected output for reporting errors
+		fmt.Fprintln(&w.errors, line)
+		return
+	}
+	w.done++
+	w.p.Send(utils.StatusMsg(strings.TrimPrefix(line, "pg_restore: ")))
+	if w.total > 0 {
+		percentage := float64(w.done) / float64(w.total)
+		if percentage > 1 {
+			percentage = 1
+		}
+		w.p.Send(utils.ProgressMsg(&percentage))
+	}
+}
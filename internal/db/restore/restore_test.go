@@ -0,0 +1,140 @@
+package restore
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/testing/apitest"
+	"github.com/supabase/cli/internal/utils"
+	"gopkg.in/h2non/gock.v1"
+)
+
+var dbConfig = pgconn.Config{
+	Host:     "127.0.0.1",
+	Port:     5432,
+	User:     "admin",
+	Password: "password",
+	Database: "postgres",
+}
+
+func TestRestoreCommand(t *testing.T) {
+	imageUrl := utils.GetRegistryImageUrl(utils.Pg15Image)
+	const containerId = "test-container"
+
+	t.Run("restores plain sql", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, "dump.sql", []byte("create table t();"), 0644))
+		// Setup mock docker
+		require.NoError(t, apitest.MockDocker(utils.Docker))
+		defer gock.OffAll()
+		apitest.MockDockerStart(utils.Docker, imageUrl, containerId)
+		require.NoError(t, apitest.MockDockerLogs(utils.Docker, containerId, "CREATE TABLE"))
+		// Run test
+		err := Run(context.Background(), "dump.sql", dbConfig, nil, false, false, 1, fsys)
+		// Check error
+		assert.NoError(t, err)
+		assert.Empty(t, apitest.ListUnmatchedRequests())
+	})
+
+	t.Run("throws error on plain sql flags", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, "dump.sql", []byte("create table t();"), 0644))
+		// Run test
+		err := Run(context.Background(), "dump.sql", dbConfig, nil, true, false, 1, fsys)
+		// Check error
+		assert.ErrorIs(t, err, errPlainFlags)
+	})
+
+	t.Run("throws error on missing file", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		// Run test
+		err := Run(context.Background(), "dump.sql", dbConfig, nil, false, false, 1, fsys)
+		// Check error
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("throws error on restore failure", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, "dump.sql", []byte("PGDMP"), 0644))
+		// Setup mock docker
+		require.NoError(t, apitest.MockDocker(utils.Docker))
+		defer gock.OffAll()
+		gock.New(utils.Docker.DaemonHost()).
+			Get("/v" + utils.Docker.ClientVersion() + "/images").
+			Reply(http.StatusServiceUnavailable)
+		// Run test
+		err := Run(context.Background(), "dump.sql", dbConfig, []string{"public"}, true, true, 4, fsys)
+		// Check error
+		assert.ErrorContains(t, err, "request returned Service Unavailable for API route and version")
+		assert.Empty(t, apitest.ListUnmatchedRequests())
+	})
+}
+
+func TestDetectFormat(t *testing.T) {
+	t.Run("detects custom format", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, "dump.bin", []byte("PGDMP\x01\x0e\x00"), 0644))
+		// Run test
+		format, err := DetectFormat("dump.bin", fsys)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, FormatCustom, format)
+	})
+
+	t.Run("detects directory format", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, filepath.Join("dump", "toc.dat"), []byte("PGDMP"), 0644))
+		// Run test
+		format, err := DetectFormat("dump", fsys)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, FormatDirectory, format)
+	})
+
+	t.Run("detects tar format", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		header := make([]byte, 512)
+		copy(header[257:], "ustar")
+		require.NoError(t, afero.WriteFile(fsys, "dump.tar", header, 0644))
+		// Run test
+		format, err := DetectFormat("dump.tar", fsys)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, FormatTar, format)
+	})
+
+	t.Run("defaults to plain format", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, "dump.sql", []byte{}, 0644))
+		// Run test
+		format, err := DetectFormat("dump.sql", fsys)
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, FormatPlain, format)
+	})
+
+	t.Run("throws error on missing toc", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, fsys.Mkdir("dump", 0755))
+		// Run test
+		_, err := DetectFormat("dump", fsys)
+		// Check error
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
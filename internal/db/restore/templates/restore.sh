@@ -0,0 +1,35 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+export PGHOST="$PGHOST"
+export PGPORT="$PGPORT"
+export PGUSER="$PGUSER"
+export PGPASSWORD="$PGPASSWORD"
+export PGDATABASE="$PGDATABASE"
+
+# Plain SQL dumps may contain copy statements, so they must be restored with psql.
+if [ "$DUMP_FORMAT" = "plain" ]; then
+    psql \
+        --no-psqlrc \
+        --echo-errors \
+        --set ON_ERROR_STOP=on \
+        --single-transaction \
+        --file "$DUMP_PATH"
+    exit
+fi
+
+# Report the number of archive items for displaying progress
+echo "Restoring $(pg_restore --list "$DUMP_PATH" | grep -cv '^;') items..." >&2
+
+# Explanation of pg_restore flags:
+#
+#   --verbose       logs each archive item to stderr for tracking progress
+#   --exit-on-error stops at the first error instead of restoring partially
+#
+# Remaining flags, such as --schema and --jobs, are passed as script arguments.
+pg_restore \
+    --verbose \
+    --exit-on-error \
+    --dbname "$PGDATABASE" \
+    "$@" \
+    "$DUMP_PATH"
@@ -0,0 +1,427 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/db/lint"
+	"github.com/supabase/cli/internal/db/reset"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const (
+	ENABLE_PLPGSQL_CHECK  = "create extension if not exists plpgsql_check with schema extensions"
+	DISABLE_PLPGSQL_CHECK = "drop extension if exists plpgsql_check"
+	// Profiles are kept in session memory unless plpgsql_check is preloaded
+	ENABLE_PROFILER = "select plpgsql_check_profiler(true)"
+	// Clears profiles left in shared memory by previous runs
+	RESET_PROFILER = "select plpgsql_profiler_reset_all()"
+	// Only lines with executable statements are counted towards coverage
+	LIST_FUNCTION_LINES = `select format('%I.%I(%s)', n.nspname, p.proname, pg_catalog.pg_get_function_identity_arguments(p.oid)),
+  (select json_agg(json_build_object('line', pr.lineno, 'hits', pr.exec_stmts) order by pr.lineno)
+  from plpgsql_profiler_function_tb(p.oid) pr
+  where pr.cmds_on_row > 0),
+  p.prosrc
+from pg_catalog.pg_proc p
+join pg_catalog.pg_namespace n on n.oid = p.pronamespace
+join pg_catalog.pg_language l on l.oid = p.prolang
+where l.lanname = 'plpgsql' and n.nspname = any($1::text[])
+order by 1`
+	LIST_POLICIES = `select schemaname::text, tablename::text, policyname::text, cmd
+from pg_catalog.pg_policies
+where schemaname = any($1::text[])
+order by 1, 2, 3`
+
+	OutputLcov      = "lcov"
+	OutputCobertura = "cobertura"
+)
+
+var AllowedCoverageOutputs = []string{
+	utils.OutputPretty,
+	utils.OutputJson,
+	OutputLcov,
+	OutputCobertura,
+}
+
+type LineCoverage struct {
+	Line int   `json:"line"`
+	Hits int64 `json:"hits"`
+}
+
+type FunctionCoverage struct {
+	Name  string         `json:"name"`
+	File  string         `json:"file,omitempty"`
+	Lines []LineCoverage `json:"lines"`
+	// Line of the opening dollar quote in migration file
+	bodyLine int
+}
+
+func (f FunctionCoverage) Covered() (count int) {
+	for _, l := range f.Lines {
+		if l.Hits > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+type PolicyCoverage struct {
+	Schema  string `json:"schema"`
+	Table   string `json:"table"`
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	// Policies are not traced, so this is only a reference check that their table
+	// appears in the statements of a test file, not that the policy was evaluated
+	Referenced bool `json:"referenced"`
+}
+
+type Coverage struct {
+	Functions []FunctionCoverage `json:"functions"`
+	Policies  []PolicyCoverage   `json:"policies"`
+	// User schemas to report coverage on
+	schemas []string
+	mu      sync.Mutex
+	hits    map[string]map[int]int64
+	sources map[string]string
+}
+
+func newCoverage(ctx context.Context, conn *pgx.Conn) (*Coverage, error) {
+	schemas, err := reset.ListSchemas(ctx, conn, append([]string{"tests"}, utils.InternalSchemas...)...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, RESET_PROFILER); err != nil {
+		return nil, errors.Errorf("failed to reset profiler: %w", err)
+	}
+	return &Coverage{schemas: schemas, hits: map[string]map[int]int64{}, sources: map[string]string{}}, nil
+}
+
+// Enables the statement profiler for the current session.
+func (c *Coverage) enable(ctx context.Context, conn *pgx.Conn) error {
+	if _, err := conn.Exec(ctx, ENABLE_PROFILER); err != nil {
+		return errors.Errorf("failed to enable profiler: %w", err)
+	}
+	return nil
+}
+
+// Merges the profile of the current session into coverage.
+func (c *Coverage) collect(ctx context.Context, conn *pgx.Conn) error {
+	rows, err := conn.Query(ctx, LIST_FUNCTION_LINES, c.schemas)
+	if err != nil {
+		return errors.Errorf("failed to query profiler: %w", err)
+	}
+	defer rows.Close()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for rows.Next() {
+		var name, source string
+		var data []byte
+		if err := rows.Scan(&name, &data, &source); err != nil {
+			return errors.Errorf("failed to scan profiler: %w", err)
+		}
+		var lines []LineCoverage
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &lines); err != nil {
+				return errors.Errorf("failed to parse profile: %w", err)
+			}
+		}
+		if _, ok := c.hits[name]; !ok {
+			c.hits[name] = map[int]int64{}
+		}
+		c.sources[name] = source
+		for _, l := range lines {
+			c.hits[name][l.Line] += l.Hits
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Errorf("failed to parse profiler: %w", err)
+	}
+	return nil
+}
+
+var sqlComment = regexp.MustCompile(`(?s)/\*.*?\*/|--[^\n]*`)
+
+// Policies are considered referenced if their table appears in any test file, excluding comments.
+func (c *Coverage) listPolicies(ctx context.Context, conn *pgx.Conn, files []string, fsys afero.Fs) error {
+	var tests []string
+	for _, fp := range files {
+		contents, err := afero.ReadFile(fsys, fp)
+		if err != nil {
+			return errors.Errorf("failed to read test file: %w", err)
+		}
+		tests = append(tests, sqlComment.ReplaceAllString(string(contents), " "))
+	}
+	rows, err := conn.Query(ctx, LIST_POLICIES, c.schemas)
+	if err != nil {
+		return errors.Errorf("failed to list policies: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p PolicyCoverage
+		if err := rows.Scan(&p.Schema, &p.Table, &p.Name, &p.Command); err != nil {
+			return errors.Errorf("failed to scan policies: %w", err)
+		}
+		pattern := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(p.Table) + `\b`)
+		for _, sql := range tests {
+			if p.Referenced = pattern.MatchString(sql); p.Referenced {
+				break
+			}
+		}
+		c.Policies = append(c.Policies, p)
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Errorf("failed to parse policies: %w", err)
+	}
+	return nil
+}
+
+// Sorts collected hits into functions, mapping them to local migrations where possible.
+func (c *Coverage) resolve(fsys afero.Fs) error {
+	sources, err := lint.LoadSources(fsys)
+	if err != nil {
+		return err
+	}
+	c.Functions = nil
+	for name, lines := range c.hits {
+		f := FunctionCoverage{Name: name}
+		if loc, ok := sources[lint.NormalizeName(name)]; ok && loc.BodyLine > 0 {
+			f.File = loc.File
+			f.bodyLine = loc.BodyLine
+		}
+		for line, hits := range lines {
+			f.Lines = append(f.Lines, LineCoverage{Line: line, Hits: hits})
+		}
+		// Functions that never ran have no profile, so count their statements from source
+		if len(f.Lines) == 0 {
+			for _, line := range statementLines(c.sources[name]) {
+				f.Lines = append(f.Lines, LineCoverage{Line: line})
+			}
+		}
+		sort.Slice(f.Lines, func(i, j int) bool {
+			return f.Lines[i].Line < f.Lines[j].Line
+		})
+		c.Functions = append(c.Functions, f)
+	}
+	sort.Slice(c.Functions, func(i, j int) bool {
+		return c.Functions[i].Name < c.Functions[j].Name
+	})
+	return nil
+}
+
+func (c *Coverage) countLines() (covered, valid int) {
+	for _, f := range c.Functions {
+		covered += f.Covered()
+		valid += len(f.Lines)
+	}
+	return covered, valid
+}
+
+func (c *Coverage) Encode(format string, w io.Writer) error {
+	switch format {
+	case OutputLcov:
+		return c.encodeLcov(w)
+	case OutputCobertura:
+		return c.encodeCobertura(w)
+	case utils.OutputJson:
+		return utils.EncodeOutput(format, w, c)
+	}
+	return c.encodePretty(w)
+}
+
+func (c *Coverage) encodePretty(w io.Writer) error {
+	width := len("Function")
+	for _, f := range c.Functions {
+		width = max(width, len(f.Name))
+	}
+	fmt.Fprintf(w, "%-*s  %9s  %8s\n", width, "Function", "Lines", "Coverage")
+	for _, f := range c.Functions {
+		lines := fmt.Sprintf("%d/%d", f.Covered(), len(f.Lines))
+		fmt.Fprintf(w, "%-*s  %9s  %8s\n", width, f.Name, lines, formatRate(f.Covered(), len(f.Lines)))
+	}
+	covered, valid := c.countLines()
+	fmt.Fprintf(w, "Covered %d of %d lines in %d functions (%s)\n", covered, valid, len(c.Functions), formatRate(covered, valid))
+	var unreferenced []PolicyCoverage
+	for _, p := range c.Policies {
+		if !p.Referenced {
+			unreferenced = append(unreferenced, p)
+		}
+	}
+	if len(unreferenced) > 0 {
+		fmt.Fprintf(w, "%s %d of %d policies are on tables not referenced by any test:\n", utils.Yellow("WARNING:"), len(unreferenced), len(c.Policies))
+		for _, p := range unreferenced {
+			fmt.Fprintf(w, "  %s.%s: %q (%s)\n", p.Schema, p.Table, p.Name, p.Command)
+		}
+	}
+	return nil
+}
+
+// Keywords that share a line with no statement of their own.
+var blockPattern = regexp.MustCompile(`(?i)^(else|exception|end(\s+(if|loop|case))?\s*;?)$`)
+
+// Approximates the lines counted by the profiler from the function body, which
+// is numbered from the opening dollar quote. Blank lines, comments, the declare
+// section and block keywords are skipped.
+func statementLines(source string) []int {
+	var result []int
+	declare := false
+	for i, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "--") || blockPattern.MatchString(line) {
+			continue
+		}
+		if strings.EqualFold(line, "declare") {
+			declare = true
+			continue
+		}
+		if declare && !strings.HasPrefix(strings.ToLower(line), "begin") {
+			continue
+		}
+		declare = false
+		result = append(result, i+1)
+	}
+	return result
+}
+
+// Functions not defined in local migrations are reported by their signature.
+func (f FunctionCoverage) sourceFile() string {
+	if len(f.File) > 0 {
+		return filepath.ToSlash(f.File)
+	}
+	return f.Name
+}
+
+func (f FunctionCoverage) sourceLine(line int) int {
+	if f.bodyLine > 0 {
+		return f.bodyLine + line - 1
+	}
+	return line
+}
+
+func (c *Coverage) encodeLcov(w io.Writer) error {
+	fmt.Fprintln(w, "TN:supabase")
+	for _, f := range c.Functions {
+		fmt.Fprintln(w, "SF:"+f.sourceFile())
+		// Hits on the first statement approximate the number of calls
+		first, calls := f.sourceLine(1), int64(0)
+		if len(f.Lines) > 0 {
+			first, calls = f.sourceLine(f.Lines[0].Line), f.Lines[0].Hits
+		}
+		fmt.Fprintf(w, "FN:%d,%s\n", first, f.Name)
+		fmt.Fprintf(w, "FNDA:%d,%s\n", calls, f.Name)
+		fmt.Fprintln(w, "FNF:1")
+		fmt.Fprintf(w, "FNH:%d\n", min(calls, 1))
+		for _, l := range f.Lines {
+			fmt.Fprintf(w, "DA:%d,%d\n", f.sourceLine(l.Line), l.Hits)
+		}
+		fmt.Fprintf(w, "LF:%d\n", len(f.Lines))
+		fmt.Fprintf(w, "LH:%d\n", f.Covered())
+		fmt.Fprintln(w, "end_of_record")
+	}
+	return nil
+}
+
+type coberturaReport struct {
+	XMLName      xml.Name           `xml:"coverage"`
+	LineRate     string             `xml:"line-rate,attr"`
+	BranchRate   string             `xml:"branch-rate,attr"`
+	LinesCovered int                `xml:"lines-covered,attr"`
+	LinesValid   int                `xml:"lines-valid,attr"`
+	Version      string             `xml:"version,attr"`
+	Timestamp    int64              `xml:"timestamp,attr"`
+	Packages     []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name     string           `xml:"name,attr"`
+	LineRate string           `xml:"line-rate,attr"`
+	Classes  []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name     string          `xml:"name,attr"`
+	Filename string          `xml:"filename,attr"`
+	LineRate string          `xml:"line-rate,attr"`
+	Lines    []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number int   `xml:"number,attr"`
+	Hits   int64 `xml:"hits,attr"`
+}
+
+func (c *Coverage) encodeCobertura(w io.Writer) error {
+	covered, valid := c.countLines()
+	report := coberturaReport{
+		LineRate:     lineRate(covered, valid),
+		BranchRate:   "0",
+		LinesCovered: covered,
+		LinesValid:   valid,
+		Version:      utils.Version,
+		Timestamp:    time.Now().UnixMilli(),
+	}
+	// Group functions by schema
+	packages := map[string]int{}
+	var pkgCovered, pkgValid []int
+	for _, f := range c.Functions {
+		schema := strings.SplitN(lint.NormalizeName(f.Name), ".", 2)[0]
+		i, ok := packages[schema]
+		if !ok {
+			i = len(report.Packages)
+			packages[schema] = i
+			report.Packages = append(report.Packages, coberturaPackage{Name: schema})
+			pkgCovered = append(pkgCovered, 0)
+			pkgValid = append(pkgValid, 0)
+		}
+		class := coberturaClass{
+			Name:     f.Name,
+			Filename: f.sourceFile(),
+			LineRate: lineRate(f.Covered(), len(f.Lines)),
+		}
+		for _, l := range f.Lines {
+			class.Lines = append(class.Lines, coberturaLine{Number: f.sourceLine(l.Line), Hits: l.Hits})
+		}
+		report.Packages[i].Classes = append(report.Packages[i].Classes, class)
+		pkgCovered[i] += f.Covered()
+		pkgValid[i] += len(f.Lines)
+	}
+	for i := range report.Packages {
+		report.Packages[i].LineRate = lineRate(pkgCovered[i], pkgValid[i])
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.Errorf("failed to write cobertura report: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return errors.Errorf("failed to write cobertura report: %w", err)
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+func lineRate(covered, valid int) string {
+	if valid == 0 {
+		return "1"
+	}
+	return strconv.FormatFloat(float64(covered)/float64(valid), 'f', 4, 64)
+}
+
+func formatRate(covered, valid int) string {
+	if valid == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(covered)*100/float64(valid))
+}
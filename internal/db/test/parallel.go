@@ -0,0 +1,150 @@
+package test
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const (
+	templateDb = "supabase_test_template"
+	// Databases are force dropped in case a test leaves behind open sessions
+	DROP_DATABASE = "DROP DATABASE IF EXISTS %s WITH (FORCE)"
+	COPY_DATABASE = "CREATE DATABASE %s TEMPLATE %s"
+)
+
+var (
+	//go:embed templates/clone.sh
+	cloneScript string
+)
+
+// Runs test files across multiple copies of the database, returning results in the same order as files.
+func runParallel(ctx context.Context, files []string, jobs uint, cov *Coverage, config pgconn.Config, conn *pgx.Conn, fsys afero.Fs, options ...func(*pgx.ConnConfig)) ([]TestFile, error) {
+	if n := uint(len(files)); jobs > n {
+		jobs = n
+	}
+	databases, err := cloneDatabase(ctx, jobs, config, conn)
+	defer func() {
+		for _, name := range append(databases, templateDb) {
+			sql := fmt.Sprintf(DROP_DATABASE, pgx.Identifier{name}.Sanitize())
+			if _, err := conn.Exec(context.Background(), sql); err != nil {
+				fmt.Fprintln(os.Stderr, "failed to drop database:", err)
+			}
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]TestFile, len(files))
+	queue := make(chan int, len(files))
+	for i := range files {
+		queue <- i
+	}
+	close(queue)
+	errCh := make(chan error, len(databases))
+	var wg sync.WaitGroup
+	for _, name := range databases {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			errCh <- runWorker(ctx, name, files, queue, results, cov, config, fsys, options...)
+		}(name)
+	}
+	wg.Wait()
+	close(errCh)
+	var errs []error
+	for err := range errCh {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+func runWorker(ctx context.Context, database string, files []string, queue <-chan int, results []TestFile, cov *Coverage, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	config.Database = database
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	if cov != nil {
+		if err := cov.enable(ctx, conn); err != nil {
+			return err
+		}
+	}
+	for i := range queue {
+		// Each index is written by exactly one worker
+		if results[i], err = runTestFile(ctx, files[i], conn.PgConn(), fsys); err != nil {
+			return err
+		}
+	}
+	if cov != nil {
+		return cov.collect(ctx, conn)
+	}
+	return nil
+}
+
+// Clones the migrated database into a template, then creates a copy of the template per job.
+func cloneDatabase(ctx context.Context, jobs uint, config pgconn.Config, conn *pgx.Conn) ([]string, error) {
+	fmt.Fprintf(os.Stderr, "Cloning database for %d parallel jobs...\n", jobs)
+	sql := fmt.Sprintf(DROP_DATABASE, pgx.Identifier{templateDb}.Sanitize())
+	if _, err := conn.Exec(ctx, sql); err != nil {
+		return nil, errors.Errorf("failed to drop template database: %w", err)
+	}
+	// Use custom network when connecting to local database
+	networkID := "host"
+	if utils.IsLocalDatabase(config) {
+		config.Host = utils.DbAliases[0]
+		config.Port = 5432
+		networkID = utils.NetId
+	}
+	if err := utils.DockerRunOnceWithConfig(
+		ctx,
+		container.Config{
+			Image: utils.Pg15Image,
+			Env: []string{
+				"PGHOST=" + config.Host,
+				fmt.Sprintf("PGPORT=%d", config.Port),
+				"PGUSER=" + config.User,
+				"PGPASSWORD=" + config.Password,
+				"PGDATABASE=" + config.Database,
+				"TEMPLATE_DB=" + templateDb,
+			},
+			Cmd: []string{"bash", "-c", cloneScript, "--"},
+		},
+		container.HostConfig{
+			NetworkMode: container.NetworkMode(networkID),
+		},
+		network.NetworkingConfig{},
+		"",
+		os.Stdout,
+		os.Stderr,
+	); err != nil {
+		return nil, errors.Errorf("failed to clone database: %w", err)
+	}
+	var databases []string
+	for i := uint(1); i <= jobs; i++ {
+		name := fmt.Sprintf("supabase_test_%d", i)
+		for _, sql := range []string{
+			fmt.Sprintf(DROP_DATABASE, pgx.Identifier{name}.Sanitize()),
+			fmt.Sprintf(COPY_DATABASE, pgx.Identifier{name}.Sanitize(), pgx.Identifier{templateDb}.Sanitize()),
+		} {
+			if _, err := conn.Exec(ctx, sql); err != nil {
+				return databases, errors.Errorf("failed to copy database: %w", err)
+			}
+		}
+		databases = append(databases, name)
+	}
+	return databases, nil
+}
@@ -0,0 +1,205 @@
+package test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const (
+	OutputTap   = "tap"
+	OutputJunit = "junit"
+)
+
+var AllowedOutputs = []string{
+	utils.OutputPretty,
+	OutputTap,
+	utils.OutputJson,
+	OutputJunit,
+}
+
+type Report struct {
+	Files    []TestFile    `json:"files"`
+	Duration time.Duration `json:"duration"`
+}
+
+func (r Report) Passed() bool {
+	for _, f := range r.Files {
+		if !f.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Report) countTests() (tests, failures int) {
+	for _, f := range r.Files {
+		tests += len(f.Cases)
+		failures += f.Failures()
+	}
+	return tests, failures
+}
+
+func (r Report) countErrors() (count int) {
+	for _, f := range r.Files {
+		if len(f.Error) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+func (r Report) Encode(format string, w io.Writer) error {
+	switch format {
+	case OutputTap:
+		return r.encodeTAP(w)
+	case OutputJunit:
+		return r.encodeJunit(w)
+	case utils.OutputJson:
+		return utils.EncodeOutput(format, w, r)
+	}
+	return r.encodePretty(w)
+}
+
+func (r Report) encodePretty(w io.Writer) error {
+	for _, f := range r.Files {
+		status := utils.Aqua("ok")
+		if !f.Passed() {
+			status = utils.Red("not ok")
+		}
+		fmt.Fprintf(w, "%s .. %s (%s)\n", f.Name, status, formatDuration(f.Duration))
+		for _, c := range f.Cases {
+			if c.Passed() {
+				continue
+			}
+			fmt.Fprintf(w, "  %s %d - %s (%s)\n", utils.Red("✗"), c.Number, c.Description, formatDuration(c.Duration))
+			for _, d := range c.Diagnostics {
+				fmt.Fprintln(w, "      "+d)
+			}
+		}
+		if f.Plan > 0 && f.Plan != len(f.Cases) {
+			fmt.Fprintf(w, "  Planned %d tests but ran %d\n", f.Plan, len(f.Cases))
+		}
+		if len(f.Error) > 0 {
+			fmt.Fprintln(w, "  "+f.Error)
+		}
+	}
+	tests, failures := r.countTests()
+	result := utils.Aqua("PASS")
+	if !r.Passed() {
+		result = utils.Red("FAIL")
+	}
+	summary := fmt.Sprintf("Files=%d, Tests=%d, Failures=%d", len(r.Files), tests, failures)
+	if errored := r.countErrors(); errored > 0 {
+		summary += fmt.Sprintf(", Errors=%d", errored)
+	}
+	fmt.Fprintf(w, "%s, %s\nResult: %s\n", summary, formatDuration(r.Duration), result)
+	return nil
+}
+
+func (r Report) encodeTAP(w io.Writer) error {
+	for _, f := range r.Files {
+		fmt.Fprintln(w, "# "+f.Name)
+		for _, line := range f.Output {
+			fmt.Fprintln(w, line)
+		}
+		if len(f.Error) > 0 {
+			fmt.Fprintln(w, "Bail out! "+strings.ReplaceAll(f.Error, "\n", " "))
+		}
+	}
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+	Error    *junitMessage   `xml:"error,omitempty"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func (r Report) encodeJunit(w io.Writer) error {
+	tests, failures := r.countTests()
+	result := junitTestSuites{
+		Tests:    tests,
+		Failures: failures,
+		Errors:   r.countErrors(),
+		Time:     formatSeconds(r.Duration),
+	}
+	for _, f := range r.Files {
+		suite := junitTestSuite{
+			Name:     filepath.ToSlash(f.Name),
+			Tests:    len(f.Cases),
+			Failures: f.Failures(),
+			Time:     formatSeconds(f.Duration),
+		}
+		for _, c := range f.Cases {
+			tc := junitTestCase{
+				Name:      fmt.Sprintf("%d - %s", c.Number, c.Description),
+				ClassName: suite.Name,
+				Time:      formatSeconds(c.Duration),
+			}
+			if c.Skip {
+				tc.Skipped = &junitMessage{Message: c.Reason}
+				suite.Skipped++
+			} else if !c.Passed() {
+				tc.Failure = &junitMessage{Message: c.Description, Text: strings.Join(c.Diagnostics, "\n")}
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		if len(f.Error) > 0 {
+			suite.Error = &junitMessage{Message: f.Error}
+			suite.Errors++
+		}
+		result.Suites = append(result.Suites, suite)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.Errorf("failed to write junit report: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return errors.Errorf("failed to write junit report: %w", err)
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	planPattern   = regexp.MustCompile(`^1\.\.(\d+)`)
+	resultPattern = regexp.MustCompile(`^(not )?ok\b\s*(\d*)\s*(?:-\s*)?([^#]*?)\s*(?:#\s*(SKIP|TODO)\S*\s*(.*))?$`)
+)
+
+type TestCase struct {
+	Number      int           `json:"number"`
+	Description string        `json:"description"`
+	Ok          bool          `json:"ok"`
+	Skip        bool          `json:"skip,omitempty"`
+	Todo        bool          `json:"todo,omitempty"`
+	Reason      string        `json:"reason,omitempty"`
+	Diagnostics []string      `json:"diagnostics,omitempty"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// Failed todo tests are expected, and do not fail the test file.
+func (c TestCase) Passed() bool {
+	return c.Ok || c.Todo
+}
+
+type TestFile struct {
+	Name     string        `json:"name"`
+	Plan     int           `json:"plan"`
+	Cases    []TestCase    `json:"cases"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+	// Raw TAP lines as emitted by pgTAP
+	Output []string `json:"-"`
+}
+
+func (f TestFile) Passed() bool {
+	if len(f.Error) > 0 || f.Plan > 0 && f.Plan != len(f.Cases) {
+		return false
+	}
+	for _, c := range f.Cases {
+		if !c.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+func (f TestFile) Failures() (count int) {
+	for _, c := range f.Cases {
+		if !c.Passed() {
+			count++
+		}
+	}
+	return count
+}
+
+// Parses a chunk of TAP output, attributing the elapsed time to tests reported in the chunk.
+func (f *TestFile) parseTAP(output string, elapsed time.Duration) {
+	var added []int
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		f.Output = append(f.Output, line)
+		if matches := planPattern.FindStringSubmatch(line); len(matches) > 1 {
+			f.Plan, _ = strconv.Atoi(matches[1])
+		} else if matches := resultPattern.FindStringSubmatch(line); len(matches) > 5 {
+			c := TestCase{
+				Ok:          len(matches[1]) == 0,
+				Description: matches[3],
+				Skip:        strings.EqualFold(matches[4], "SKIP"),
+				Todo:        strings.EqualFold(matches[4], "TODO"),
+				Reason:      matches[5],
+			}
+			if c.Number, _ = strconv.Atoi(matches[2]); c.Number == 0 {
+				c.Number = len(f.Cases) + 1
+			}
+			f.Cases = append(f.Cases, c)
+			added = append(added, len(f.Cases)-1)
+		} else if strings.HasPrefix(strings.TrimSpace(line), "#") && len(f.Cases) > 0 {
+			// Diagnostics are reported after the failed test, other output is ignored
+			last := &f.Cases[len(f.Cases)-1]
+			last.Diagnostics = append(last.Diagnostics, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#")))
+		}
+	}
+	for _, i := range added {
+		f.Cases[i].Duration = elapsed / time.Duration(len(added))
+	}
+}
@@ -0,0 +1,18 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+export PGHOST="$PGHOST"
+export PGPORT="$PGPORT"
+export PGUSER="$PGUSER"
+export PGPASSWORD="$PGPASSWORD"
+export PGDATABASE="$PGDATABASE"
+
+# Template database is cloned by dump and restore because CREATE DATABASE ... TEMPLATE
+# requires no other sessions to be connected to the source database.
+createdb "$TEMPLATE_DB"
+# Ownership and event triggers can only be restored by superuser, so they are skipped. Grants
+# are kept for tests that switch roles, but those on platform objects fail to restore. Such
+# errors are reported without aborting the clone.
+pg_dump --no-owner "$PGDATABASE" \
+| sed -E '/^CREATE EVENT TRIGGER /,/;$/d; /^COMMENT ON EVENT TRIGGER /d' \
+| psql --quiet --no-psqlrc --dbname "$TEMPLATE_DB" > /dev/null
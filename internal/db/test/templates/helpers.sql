@@ -0,0 +1,72 @@
+-- Helpers for testing row level security, installed before running tests.
+CREATE SCHEMA tests;
+GRANT USAGE ON SCHEMA tests TO anon, authenticated, service_role;
+
+-- Creates a user in auth.users, returning its id.
+CREATE FUNCTION tests.create_user(email text, metadata jsonb DEFAULT '{}') RETURNS uuid
+LANGUAGE plpgsql SECURITY INVOKER SET search_path = '' AS $$
+DECLARE
+  user_id uuid := gen_random_uuid();
+BEGIN
+  INSERT INTO auth.users (instance_id, id, aud, role, email, raw_app_meta_data, raw_user_meta_data, created_at, updated_at)
+  VALUES ('00000000-0000-0000-0000-000000000000', user_id, 'authenticated', 'authenticated', create_user.email,
+    '{"provider": "email", "providers": ["email"]}', create_user.metadata, now(), now());
+  RETURN user_id;
+END;
+$$;
+
+-- Impersonates an existing user by setting the JWT claims and switching to authenticated role.
+CREATE FUNCTION tests.authenticate_as(email text) RETURNS void
+LANGUAGE plpgsql SECURITY INVOKER SET search_path = '' AS $$
+DECLARE
+  claims jsonb;
+BEGIN
+  SELECT jsonb_build_object('sub', u.id, 'email', u.email, 'role', 'authenticated', 'aud', 'authenticated',
+    'app_metadata', u.raw_app_meta_data, 'user_metadata', u.raw_user_meta_data)
+  INTO claims FROM auth.users u WHERE u.email = authenticate_as.email;
+  IF claims IS NULL THEN
+    RAISE EXCEPTION 'User with email % not found', authenticate_as.email;
+  END IF;
+  PERFORM set_config('request.jwt.claims', claims::text, true);
+  PERFORM set_config('request.jwt.claim.sub', claims ->> 'sub', true);
+  PERFORM set_config('role', 'authenticated', true);
+END;
+$$;
+
+-- Resets to an anonymous request.
+CREATE FUNCTION tests.clear_authentication() RETURNS void
+LANGUAGE plpgsql SECURITY INVOKER SET search_path = '' AS $$
+BEGIN
+  PERFORM set_config('request.jwt.claims', '{"role": "anon"}', true);
+  PERFORM set_config('request.jwt.claim.sub', '', true);
+  PERFORM set_config('role', 'anon', true);
+END;
+$$;
+
+-- Asserts that row level security is enabled on every table in a schema.
+CREATE FUNCTION tests.rls_enabled(schema_name text) RETURNS text
+LANGUAGE plpgsql SECURITY INVOKER SET search_path = '' AS $$
+DECLARE
+  missing text[];
+  pgtap_schema name;
+  result text;
+BEGIN
+  SELECT array_agg(format('%I.%I', n.nspname, c.relname) ORDER BY c.relname) INTO missing
+  FROM pg_catalog.pg_class c JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+  WHERE n.nspname = schema_name AND c.relkind IN ('r', 'p') AND NOT c.relrowsecurity;
+  -- pgTAP may have been installed in any schema before running tests
+  SELECT n.nspname INTO pgtap_schema
+  FROM pg_catalog.pg_extension e JOIN pg_catalog.pg_namespace n ON n.oid = e.extnamespace
+  WHERE e.extname = 'pgtap';
+  IF pgtap_schema IS NULL THEN
+    RAISE EXCEPTION 'pgTAP extension is not installed';
+  END IF;
+  EXECUTE format('SELECT %1$I.ok($1, $2) || CASE WHEN $1 THEN '''' ELSE E''\n'' || %1$I.diag($3) END', pgtap_schema)
+  INTO result
+  USING missing IS NULL, format('Row level security is enabled on all tables in schema %I', schema_name),
+    'Missing on: ' || array_to_string(missing, ', ');
+  RETURN result;
+END;
+$$;
+
+GRANT EXECUTE ON ALL FUNCTIONS IN SCHEMA tests TO anon, authenticated, service_role;
@@ -4,11 +4,14 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/network"
 	"github.com/go-errors/errors"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
@@ -16,47 +19,60 @@ import (
 	"github.com/spf13/afero"
 	"github.com/spf13/viper"
 	"github.com/supabase/cli/internal/utils"
+	"github.com/supabase/cli/internal/utils/parser"
 )
 
 const (
 	ENABLE_PGTAP  = "create extension if not exists pgtap with schema extensions"
 	DISABLE_PGTAP = "drop extension if exists pgtap"
+	DROP_HELPERS  = "drop schema if exists tests cascade"
 )
 
-func Run(ctx context.Context, testFiles []string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
-	// Build test command
-	cmd := []string{"pg_prove", "--ext", ".pg", "--ext", ".sql", "-r"}
-	for _, fp := range testFiles {
-		relPath, err := filepath.Rel(utils.DbTestsDir, fp)
-		if err != nil {
-			return errors.Errorf("failed to resolve relative path: %w", err)
-		}
-		cmd = append(cmd, relPath)
-	}
-	if viper.GetBool("DEBUG") {
-		cmd = append(cmd, "--verbose")
+var (
+	//go:embed templates/helpers.sql
+	ENABLE_HELPERS string
+)
+
+// Transaction statements in test files are skipped to keep the test isolated.
+var txControlPattern = regexp.MustCompile(`(?i)^(begin|start\s+transaction|commit|end|abort|rollback)(\s+(work|transaction))?$`)
+
+func Run(ctx context.Context, testFiles []string, output, outputFile string, jobs uint, coverage bool, coverageOutput, coverageFile string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	files, err := listTestFiles(testFiles, fsys)
+	if err != nil {
+		return err
 	}
-	// Mount tests directory into container as working directory
-	srcPath, err := filepath.Abs(utils.DbTestsDir)
+	report, cov, err := runSuite(ctx, files, jobs, coverage, config, fsys, options...)
 	if err != nil {
-		return errors.Errorf("failed to resolve absolute path: %w", err)
+		return err
+	}
+	err = writeReport(report, output, outputFile, fsys)
+	// Coverage is reported even when tests fail
+	if cov != nil {
+		if err := writeOutput(cov, coverageOutput, coverageFile, fsys); err != nil {
+			return err
+		}
 	}
-	dstPath := "/tmp"
-	binds := []string{fmt.Sprintf("%s:%s:ro,z", srcPath, dstPath)}
+	return err
+}
+
+// Runs test files against the database, optionally collecting coverage of database functions.
+func runSuite(ctx context.Context, files []string, jobs uint, coverage bool, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) (Report, *Coverage, error) {
+	var report Report
 	// Enable pgTAP if not already exists
 	alreadyExists := false
-	options = append(options, func(cc *pgx.ConnConfig) {
+	// Parallel workers must not share the notice hook, which writes to alreadyExists
+	connOptions := append(options[:len(options):len(options)], func(cc *pgx.ConnConfig) {
 		cc.OnNotice = func(pc *pgconn.PgConn, n *pgconn.Notice) {
 			alreadyExists = n.Code == pgerrcode.DuplicateObject
 		}
 	})
-	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	conn, err := utils.ConnectByConfig(ctx, config, connOptions...)
 	if err != nil {
-		return err
+		return report, nil, err
 	}
 	defer conn.Close(context.Background())
 	if _, err := conn.Exec(ctx, ENABLE_PGTAP); err != nil {
-		return errors.Errorf("failed to enable pgTAP: %w", err)
+		return report, nil, errors.Errorf("failed to enable pgTAP: %w", err)
 	}
 	if !alreadyExists {
 		defer func() {
@@ -65,35 +81,221 @@ func Run(ctx context.Context, testFiles []string, config pgconn.Config, fsys afe
 			}
 		}()
 	}
-	// Use custom network when connecting to local database
-	networkID := "host"
-	if utils.IsLocalDatabase(config) {
-		config.Host = utils.DbAliases[0]
-		config.Port = 5432
-		networkID = utils.NetId
-	}
-	// Run pg_prove on volume mount
-	return utils.DockerRunOnceWithConfig(
-		ctx,
-		container.Config{
-			Image: utils.PgProveImage,
-			Env: []string{
-				"PGHOST=" + config.Host,
-				fmt.Sprintf("PGPORT=%d", config.Port),
-				"PGUSER=" + config.User,
-				"PGPASSWORD=" + config.Password,
-				"PGDATABASE=" + config.Database,
-			},
-			Cmd:        cmd,
-			WorkingDir: dstPath,
-		},
-		container.HostConfig{
-			NetworkMode: container.NetworkMode(networkID),
-			Binds:       binds,
-		},
-		network.NetworkingConfig{},
-		"",
-		os.Stdout,
-		os.Stderr,
-	)
+	// Install helpers after pgTAP so that they are cloned for parallel jobs
+	if installed, err := enableHelpers(ctx, conn); err != nil {
+		return report, nil, err
+	} else if installed {
+		defer func() {
+			if _, err := conn.Exec(ctx, DROP_HELPERS); err != nil {
+				fmt.Fprintln(os.Stderr, "failed to drop test helpers:", err)
+			}
+		}()
+	}
+	var cov *Coverage
+	if coverage {
+		alreadyExists = false
+		if _, err := conn.Exec(ctx, ENABLE_PLPGSQL_CHECK); err != nil {
+			return report, nil, errors.Errorf("failed to enable plpgsql_check: %w", err)
+		}
+		if !alreadyExists {
+			defer func() {
+				if _, err := conn.Exec(ctx, DISABLE_PLPGSQL_CHECK); err != nil {
+					fmt.Fprintln(os.Stderr, "failed to disable plpgsql_check:", err)
+				}
+			}()
+		}
+		if cov, err = newCoverage(ctx, conn); err != nil {
+			return report, nil, err
+		}
+	}
+	start := time.Now()
+	if jobs > 1 && len(files) > 1 {
+		if report.Files, err = runParallel(ctx, files, jobs, cov, config, conn, fsys, options...); err != nil {
+			return report, nil, err
+		}
+	} else {
+		if report.Files, err = runSerial(ctx, files, cov, conn, fsys); err != nil {
+			return report, nil, err
+		}
+	}
+	report.Duration = time.Since(start)
+	if cov != nil {
+		if err := cov.listPolicies(ctx, conn, files, fsys); err != nil {
+			return report, nil, err
+		}
+		if err := cov.resolve(fsys); err != nil {
+			return report, nil, err
+		}
+	}
+	return report, cov, nil
+}
+
+func runSerial(ctx context.Context, files []string, cov *Coverage, conn *pgx.Conn, fsys afero.Fs) ([]TestFile, error) {
+	if cov != nil {
+		if err := cov.enable(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+	var results []TestFile
+	for _, fp := range files {
+		result, err := runTestFile(ctx, fp, conn.PgConn(), fsys)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	if cov != nil {
+		if err := cov.collect(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// Installs helpers for testing RLS policies in the tests schema.
+func enableHelpers(ctx context.Context, conn *pgx.Conn) (bool, error) {
+	if _, err := conn.Exec(ctx, ENABLE_HELPERS); err != nil {
+		var pgErr *pgconn.PgError
+		// Skip helpers so as not to drop a user defined schema
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.DuplicateSchema {
+			fmt.Fprintln(os.Stderr, "Skipping test helpers because schema already exists:", utils.Bold("tests"))
+			return false, nil
+		}
+		return false, errors.Errorf("failed to install test helpers: %w", err)
+	}
+	return true, nil
+}
+
+type encoder interface {
+	Encode(format string, w io.Writer) error
+}
+
+func writeOutput(result encoder, output, outputFile string, fsys afero.Fs) error {
+	if len(outputFile) > 0 {
+		f, err := fsys.OpenFile(outputFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return errors.Errorf("failed to open report file: %w", err)
+		}
+		defer f.Close()
+		if err := result.Encode(output, f); err != nil {
+			return err
+		}
+		// Always show a summary on console
+		output = utils.OutputPretty
+	}
+	return result.Encode(output, os.Stdout)
+}
+
+func writeReport(report Report, output, outputFile string, fsys afero.Fs) error {
+	if err := writeOutput(report, output, outputFile, fsys); err != nil {
+		return err
+	}
+	if report.Passed() {
+		return nil
+	}
+	// Files that error before any assertion would otherwise report 0 failed tests
+	var msgs []string
+	if tests, failures := report.countTests(); failures > 0 {
+		msgs = append(msgs, fmt.Sprintf("%d of %d tests failed.", failures, tests))
+	}
+	if errored := report.countErrors(); errored > 0 {
+		msgs = append(msgs, fmt.Sprintf("%d of %d test files failed with errors.", errored, len(report.Files)))
+	}
+	if len(msgs) == 0 {
+		msgs = append(msgs, "Some test files did not run all planned tests.")
+	}
+	return errors.New(strings.Join(msgs, " "))
+}
+
+// Expands directories into test files ending with .sql or .pg, similar to pg_prove -r.
+func listTestFiles(testFiles []string, fsys afero.Fs) ([]string, error) {
+	if len(testFiles) == 0 {
+		testFiles = append(testFiles, utils.DbTestsDir)
+	}
+	var result []string
+	for _, fp := range testFiles {
+		if err := afero.Walk(fsys, fp, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			if ext := filepath.Ext(path); path == fp || ext == ".sql" || ext == ".pg" {
+				result = append(result, path)
+			}
+			return nil
+		}); err != nil {
+			return nil, errors.Errorf("failed to list test files: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func runTestFile(ctx context.Context, fp string, conn *pgconn.PgConn, fsys afero.Fs) (TestFile, error) {
+	result := TestFile{Name: fp}
+	if rel, err := filepath.Rel(utils.DbTestsDir, fp); err == nil && !strings.HasPrefix(rel, "..") {
+		result.Name = rel
+	}
+	sql, err := fsys.Open(fp)
+	if err != nil {
+		return result, errors.Errorf("failed to open test file: %w", err)
+	}
+	defer sql.Close()
+	lines, err := parser.SplitAndTrim(sql)
+	if err != nil {
+		return result, errors.Errorf("failed to parse test file: %w", err)
+	}
+	// Meta-commands are only understood by psql, which used to run test files through pg_prove
+	for _, line := range lines {
+		if stmt := trimLeadingComments(line); strings.HasPrefix(stmt, `\`) {
+			command, _, _ := strings.Cut(stmt, "\n")
+			result.Error = "psql meta-commands are not supported: " + command
+			return result, nil
+		}
+	}
+	// Each test file is isolated in its own transaction
+	if err := conn.Exec(ctx, "BEGIN").Close(); err != nil {
+		return result, errors.Errorf("failed to begin transaction: %w", err)
+	}
+	start := time.Now()
+	for _, line := range lines {
+		if txControlPattern.MatchString(trimLeadingComments(line)) {
+			continue
+		}
+		begin := time.Now()
+		results, err := conn.Exec(ctx, line).ReadAll()
+		var out strings.Builder
+		for _, r := range results {
+			for _, row := range r.Rows {
+				if len(row) > 0 {
+					fmt.Fprintln(&out, string(row[0]))
+				}
+			}
+		}
+		if viper.GetBool("DEBUG") {
+			fmt.Fprint(os.Stderr, out.String())
+		}
+		result.parseTAP(out.String(), time.Since(begin))
+		if err != nil {
+			result.Error = err.Error()
+			break
+		}
+	}
+	result.Duration = time.Since(start)
+	if err := conn.Exec(ctx, "ROLLBACK").Close(); err != nil {
+		return result, errors.Errorf("failed to rollback transaction: %w", err)
+	}
+	return result, nil
+}
+
+func trimLeadingComments(sql string) string {
+	for strings.HasPrefix(sql, "--") {
+		index := strings.IndexByte(sql, '\n')
+		if index < 0 {
+			return ""
+		}
+		sql = strings.TrimSpace(sql[index+1:])
+	}
+	return sql
 }
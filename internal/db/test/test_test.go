@@ -3,13 +3,19 @@ package test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/db/reset"
 	"github.com/supabase/cli/internal/testing/apitest"
 	"github.com/supabase/cli/internal/testing/pgtest"
 	"github.com/supabase/cli/internal/utils"
@@ -25,35 +31,120 @@ var dbConfig = pgconn.Config{
 }
 
 func TestRunCommand(t *testing.T) {
-	t.Run("runs tests with pg_prove", func(t *testing.T) {
+	t.Run("runs tests with pgTAP", func(t *testing.T) {
 		// Setup in-memory fs
 		fsys := afero.NewMemMapFs()
 		require.NoError(t, utils.WriteConfig(fsys, false))
+		testPath := filepath.Join(utils.DbTestsDir, "nested", "test.sql")
+		require.NoError(t, afero.WriteFile(fsys, testPath, []byte("begin;\nselect plan(1);\nselect ok(true, 'works');\nrollback;"), 0644))
 		// Setup mock postgres
 		conn := pgtest.NewConn()
 		defer conn.Close(t)
 		conn.Query(ENABLE_PGTAP).
 			Reply("CREATE EXTENSION").
+			Query(ENABLE_HELPERS).
+			Reply("GRANT").
+			Query("BEGIN").
+			Reply("BEGIN").
+			Query("select plan(1)").
+			Reply("SELECT 1", []interface{}{"1..1"}).
+			Query("select ok(true, 'works')").
+			Reply("SELECT 1", []interface{}{"ok 1 - works"}).
+			Query("ROLLBACK").
+			Reply("ROLLBACK").
+			Query(DROP_HELPERS).
+			Reply("DROP SCHEMA").
+			Query(DISABLE_PGTAP).
+			Reply("DROP EXTENSION")
+		// Run test
+		err := Run(context.Background(), nil, utils.OutputPretty, "", 1, false, "", "", dbConfig, fsys, conn.Intercept)
+		// Check error
+		assert.NoError(t, err)
+	})
+
+	t.Run("writes junit report on failure", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, utils.WriteConfig(fsys, false))
+		testPath := filepath.Join(utils.DbTestsDir, "test.sql")
+		require.NoError(t, afero.WriteFile(fsys, testPath, []byte("select ok(false, 'fails');"), 0644))
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(ENABLE_PGTAP).
+			Reply("CREATE EXTENSION").
+			Query(ENABLE_HELPERS).
+			Reply("GRANT").
+			Query("BEGIN").
+			Reply("BEGIN").
+			Query("select ok(false, 'fails')").
+			Reply("SELECT 1", []interface{}{"not ok 1 - fails\n# Failed test 1: \"fails\""}).
+			Query("ROLLBACK").
+			Reply("ROLLBACK").
+			Query(DROP_HELPERS).
+			Reply("DROP SCHEMA").
+			Query(DISABLE_PGTAP).
+			Reply("DROP EXTENSION")
+		// Run test
+		err := Run(context.Background(), []string{testPath}, OutputJunit, "report.xml", 1, false, "", "", dbConfig, fsys, conn.Intercept)
+		// Check error
+		assert.ErrorContains(t, err, "1 of 1 tests failed.")
+		report, err := afero.ReadFile(fsys, "report.xml")
+		assert.NoError(t, err)
+		assert.Contains(t, string(report), `<testsuite name="test.sql" tests="1" failures="1" errors="0" skipped="0"`)
+		assert.Contains(t, string(report), `<failure message="fails">Failed test 1: &#34;fails&#34;</failure>`)
+	})
+
+	t.Run("throws error on clone failure", func(t *testing.T) {
+		errNetwork := errors.New("network error")
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, filepath.Join(utils.DbTestsDir, "a.sql"), []byte("select 1;"), 0644))
+		require.NoError(t, afero.WriteFile(fsys, filepath.Join(utils.DbTestsDir, "b.sql"), []byte("select 1;"), 0644))
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		dropTemplate := fmt.Sprintf(DROP_DATABASE, `"`+templateDb+`"`)
+		conn.Query(ENABLE_PGTAP).
+			Reply("CREATE EXTENSION").
+			Query(ENABLE_HELPERS).
+			Reply("GRANT").
+			Query(dropTemplate).
+			Reply("DROP DATABASE").
+			Query(dropTemplate).
+			Reply("DROP DATABASE").
+			Query(DROP_HELPERS).
+			Reply("DROP SCHEMA").
 			Query(DISABLE_PGTAP).
 			Reply("DROP EXTENSION")
 		// Setup mock docker
 		require.NoError(t, apitest.MockDocker(utils.Docker))
 		defer gock.OffAll()
-		containerId := "test-pg-prove"
-		apitest.MockDockerStart(utils.Docker, utils.GetRegistryImageUrl(utils.PgProveImage), containerId)
-		require.NoError(t, apitest.MockDockerLogs(utils.Docker, containerId, "Result: SUCCESS"))
+		gock.New(utils.Docker.DaemonHost()).
+			Get("/v" + utils.Docker.ClientVersion() + "/images/" + utils.GetRegistryImageUrl(utils.Pg15Image) + "/json").
+			ReplyError(errNetwork)
 		// Run test
-		err := Run(context.Background(), []string{"nested"}, dbConfig, fsys, conn.Intercept)
+		err := Run(context.Background(), nil, utils.OutputPretty, "", 4, false, "", "", dbConfig, fsys, conn.Intercept)
 		// Check error
-		assert.NoError(t, err)
+		assert.ErrorIs(t, err, errNetwork)
+		assert.Empty(t, apitest.ListUnmatchedRequests())
+	})
+
+	t.Run("throws error on missing tests", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		// Run test
+		err := Run(context.Background(), nil, utils.OutputPretty, "", 1, false, "", "", dbConfig, fsys)
+		// Check error
+		assert.ErrorIs(t, err, os.ErrNotExist)
 	})
 
 	t.Run("throws error on connect failure", func(t *testing.T) {
 		// Setup in-memory fs
 		fsys := afero.NewMemMapFs()
-		require.NoError(t, utils.WriteConfig(fsys, false))
+		require.NoError(t, fsys.MkdirAll(utils.DbTestsDir, 0755))
 		// Run test
-		err := Run(context.Background(), nil, dbConfig, fsys)
+		err := Run(context.Background(), nil, utils.OutputPretty, "", 1, false, "", "", dbConfig, fsys)
 		// Check error
 		assert.ErrorContains(t, err, "failed to connect to postgres")
 	})
@@ -61,40 +152,314 @@ func TestRunCommand(t *testing.T) {
 	t.Run("throws error on pgtap failure", func(t *testing.T) {
 		// Setup in-memory fs
 		fsys := afero.NewMemMapFs()
-		require.NoError(t, utils.WriteConfig(fsys, false))
+		require.NoError(t, fsys.MkdirAll(utils.DbTestsDir, 0755))
 		// Setup mock postgres
 		conn := pgtest.NewConn()
 		defer conn.Close(t)
 		conn.Query(ENABLE_PGTAP).
 			ReplyError(pgerrcode.DuplicateObject, `extension "pgtap" already exists, skipping`)
 		// Run test
-		err := Run(context.Background(), nil, dbConfig, fsys, conn.Intercept)
+		err := Run(context.Background(), nil, utils.OutputPretty, "", 1, false, "", "", dbConfig, fsys, conn.Intercept)
 		// Check error
 		assert.ErrorContains(t, err, "failed to enable pgTAP")
 	})
 
-	t.Run("throws error on network failure", func(t *testing.T) {
-		errNetwork := errors.New("network error")
+	t.Run("skips helpers on existing schema", func(t *testing.T) {
 		// Setup in-memory fs
 		fsys := afero.NewMemMapFs()
-		require.NoError(t, utils.WriteConfig(fsys, false))
+		require.NoError(t, afero.WriteFile(fsys, filepath.Join(utils.DbTestsDir, "a.sql"), []byte("select ok(true)"), 0644))
 		// Setup mock postgres
 		conn := pgtest.NewConn()
 		defer conn.Close(t)
 		conn.Query(ENABLE_PGTAP).
 			Reply("CREATE EXTENSION").
+			Query(ENABLE_HELPERS).
+			ReplyError(pgerrcode.DuplicateSchema, `schema "tests" already exists`).
+			Query("BEGIN").
+			Reply("BEGIN").
+			Query("select ok(true)").
+			Reply("SELECT 1", []interface{}{"ok 1"}).
+			Query("ROLLBACK").
+			Reply("ROLLBACK").
 			Query(DISABLE_PGTAP).
 			Reply("DROP EXTENSION")
-		// Setup mock docker
-		require.NoError(t, apitest.MockDocker(utils.Docker))
-		defer gock.OffAll()
-		gock.New(utils.Docker.DaemonHost()).
-			Get("/v" + utils.Docker.ClientVersion() + "/images/" + utils.GetRegistryImageUrl(utils.PgProveImage) + "/json").
-			ReplyError(errNetwork)
 		// Run test
-		err := Run(context.Background(), nil, dbConfig, fsys, conn.Intercept)
+		err := Run(context.Background(), nil, utils.OutputPretty, "", 1, false, "", "", dbConfig, fsys, conn.Intercept)
 		// Check error
-		assert.ErrorIs(t, err, errNetwork)
-		assert.Empty(t, apitest.ListUnmatchedRequests())
+		assert.NoError(t, err)
+	})
+
+	t.Run("throws error on helpers failure", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, fsys.MkdirAll(utils.DbTestsDir, 0755))
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(ENABLE_PGTAP).
+			Reply("CREATE EXTENSION").
+			Query(ENABLE_HELPERS).
+			ReplyError(pgerrcode.UndefinedTable, `relation "auth.users" does not exist`).
+			Query(DISABLE_PGTAP).
+			Reply("DROP EXTENSION")
+		// Run test
+		err := Run(context.Background(), nil, utils.OutputPretty, "", 1, false, "", "", dbConfig, fsys, conn.Intercept)
+		// Check error
+		assert.ErrorContains(t, err, "failed to install test helpers")
+	})
+}
+
+func TestTransactionControl(t *testing.T) {
+	for _, sql := range []string{"begin", "BEGIN WORK", "start transaction", "commit", "END", "rollback"} {
+		assert.True(t, txControlPattern.MatchString(sql), sql)
+	}
+	for _, sql := range []string{"rollback to savepoint a", "select begin()", "savepoint a"} {
+		assert.False(t, txControlPattern.MatchString(sql), sql)
+	}
+	assert.Equal(t, "begin", trimLeadingComments("-- setup\nbegin"))
+}
+
+func TestMetaCommands(t *testing.T) {
+	// Setup in-memory fs
+	fsys := afero.NewMemMapFs()
+	testPath := filepath.Join(utils.DbTestsDir, "meta.sql")
+	require.NoError(t, afero.WriteFile(fsys, testPath, []byte("select plan(1);\n-- setup\n\\set uid 1\nselect ok(true);"), 0644))
+	// Run test: meta-commands are rejected before connecting
+	result, err := runTestFile(context.Background(), testPath, nil, fsys)
+	// Check error
+	assert.NoError(t, err)
+	assert.Equal(t, `psql meta-commands are not supported: \set uid 1`, result.Error)
+	assert.False(t, result.Passed())
+}
+
+func TestWriteReport(t *testing.T) {
+	t.Run("reports file errors separately", func(t *testing.T) {
+		report := Report{Files: []TestFile{
+			{Name: "a.sql", Cases: []TestCase{{Number: 1, Ok: true}}},
+			{Name: "b.sql", Error: "ERROR: syntax error at or near \"selec\" (SQLSTATE 42601)"},
+		}}
+		// Run test
+		err := writeReport(report, utils.OutputJson, "", afero.NewMemMapFs())
+		// Check error
+		assert.EqualError(t, err, "1 of 2 test files failed with errors.")
+	})
+
+	t.Run("reports failed tests and file errors", func(t *testing.T) {
+		report := Report{Files: []TestFile{
+			{Name: "a.sql", Cases: []TestCase{{Number: 1}, {Number: 2, Ok: true}}},
+			{Name: "b.sql", Error: "canceled"},
+		}}
+		// Run test
+		err := writeReport(report, utils.OutputJson, "", afero.NewMemMapFs())
+		// Check error
+		assert.EqualError(t, err, "1 of 2 tests failed. 1 of 2 test files failed with errors.")
+	})
+
+	t.Run("reports incomplete plan", func(t *testing.T) {
+		report := Report{Files: []TestFile{
+			{Name: "a.sql", Plan: 2, Cases: []TestCase{{Number: 1, Ok: true}}},
+		}}
+		// Run test
+		err := writeReport(report, utils.OutputJson, "", afero.NewMemMapFs())
+		// Check error
+		assert.EqualError(t, err, "Some test files did not run all planned tests.")
+	})
+}
+
+func TestFilterChanged(t *testing.T) {
+	files := []string{
+		filepath.Join(utils.DbTestsDir, "a_test.sql"),
+		filepath.Join(utils.DbTestsDir, "nested", "b_test.sql"),
+	}
+	// Run test
+	affected, schema := filterChanged(files, []string{
+		filepath.Join(utils.DbTestsDir, "nested", "b_test.sql"),
+		filepath.Join(utils.DbTestsDir, "README.md"),
+		filepath.Join(utils.MigrationsDir, "0_init.sql"),
+		utils.SeedDataPath,
+	})
+	// Check result
+	assert.Equal(t, []string{filepath.Join(utils.DbTestsDir, "nested", "b_test.sql")}, affected)
+	assert.Equal(t, []string{filepath.Join(utils.MigrationsDir, "0_init.sql"), utils.SeedDataPath}, schema)
+}
+
+func TestParseTAP(t *testing.T) {
+	t.Run("parses test results", func(t *testing.T) {
+		var result TestFile
+		// Run test
+		result.parseTAP(`1..4
+ok 1 - has table
+not ok 2 - policy works
+# Failed test 2: "policy works"
+#         have: 0
+#         want: 1
+ok 3 # SKIP no auth schema
+not ok 4 - later # TODO fix
+`, 4*time.Millisecond)
+		// Check result
+		assert.Equal(t, 4, result.Plan)
+		require.Len(t, result.Cases, 4)
+		assert.Equal(t, TestCase{Number: 1, Description: "has table", Ok: true, Duration: time.Millisecond}, result.Cases[0])
+		assert.Equal(t, []string{`Failed test 2: "policy works"`, "have: 0", "want: 1"}, result.Cases[1].Diagnostics)
+		assert.True(t, result.Cases[2].Skip)
+		assert.Equal(t, "no auth schema", result.Cases[2].Reason)
+		assert.True(t, result.Cases[3].Todo)
+		assert.True(t, result.Cases[3].Passed())
+		assert.False(t, result.Passed())
+		assert.Equal(t, 1, result.Failures())
+	})
+
+	t.Run("fails on plan mismatch", func(t *testing.T) {
+		var result TestFile
+		// Run test
+		result.parseTAP("1..2\nok 1\n", 0)
+		// Check result
+		assert.False(t, result.Passed())
+		assert.Equal(t, 0, result.Failures())
+	})
+}
+
+func TestCoverage(t *testing.T) {
+	replacer := strings.NewReplacer("_", `\_`, "*", "%")
+	var excludeSchemas []string
+	for _, s := range append([]string{"tests"}, utils.InternalSchemas...) {
+		excludeSchemas = append(excludeSchemas, replacer.Replace(s))
+	}
+
+	t.Run("writes lcov report", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, filepath.Join(utils.DbTestsDir, "a.sql"), []byte("select ok(public.inc(1) = 2)"), 0644))
+		migration := "create table public.profiles (id int);\ncreate function public.inc(i int) returns int as $$\nbegin\n  return i + 1;\nend\n$$ language plpgsql;\n"
+		require.NoError(t, afero.WriteFile(fsys, filepath.Join(utils.MigrationsDir, "0_init.sql"), []byte(migration), 0644))
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(ENABLE_PGTAP).
+			Reply("CREATE EXTENSION").
+			Query(ENABLE_HELPERS).
+			Reply("GRANT").
+			Query(ENABLE_PLPGSQL_CHECK).
+			Reply("CREATE EXTENSION").
+			Query(reset.LIST_SCHEMAS, excludeSchemas).
+			Reply("SELECT 1", []interface{}{"public"}).
+			Query(RESET_PROFILER).
+			Reply("SELECT 1", []interface{}{nil}).
+			Query(ENABLE_PROFILER).
+			Reply("SELECT 1", []interface{}{true}).
+			Query("BEGIN").
+			Reply("BEGIN").
+			Query("select ok(public.inc(1) = 2)").
+			Reply("SELECT 1", []interface{}{"ok 1"}).
+			Query("ROLLBACK").
+			Reply("ROLLBACK").
+			Query(LIST_FUNCTION_LINES, []string{"public"}).
+			Reply("SELECT 2",
+				[]interface{}{"public.inc(i integer)", `[{"line":2,"hits":1},{"line":3,"hits":0}]`, "\nbegin\n  return i + 1;\nend\n"},
+				[]interface{}{"public.noop()", nil, "\ndeclare\n  x int;\nbegin\n  -- unused\n  x := 1;\nend\n"},
+			).
+			Query(LIST_POLICIES, []string{"public"}).
+			Reply("SELECT 2",
+				[]interface{}{"public", "profiles", "Users can view own profile", "SELECT"},
+				[]interface{}{"public", "posts", "Authors can delete posts", "DELETE"},
+			).
+			Query(DISABLE_PLPGSQL_CHECK).
+			Reply("DROP EXTENSION").
+			Query(DROP_HELPERS).
+			Reply("DROP SCHEMA").
+			Query(DISABLE_PGTAP).
+			Reply("DROP EXTENSION")
+		// Run test
+		err := Run(context.Background(), nil, utils.OutputPretty, "", 1, true, OutputLcov, "lcov.info", dbConfig, fsys, conn.Intercept)
+		// Check error
+		assert.NoError(t, err)
+		report, err := afero.ReadFile(fsys, "lcov.info")
+		assert.NoError(t, err)
+		assert.Equal(t, `TN:supabase
+SF:supabase/migrations/0_init.sql
+FN:3,public.inc(i integer)
+FNDA:1,public.inc(i integer)
+FNF:1
+FNH:1
+DA:3,1
+DA:4,0
+LF:2
+LH:1
+end_of_record
+SF:public.noop()
+FN:4,public.noop()
+FNDA:0,public.noop()
+FNF:1
+FNH:0
+DA:4,0
+DA:6,0
+LF:2
+LH:0
+end_of_record
+`, string(report))
+	})
+
+	t.Run("ignores tables named in comments", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		testPath := filepath.Join(utils.DbTestsDir, "a.sql")
+		require.NoError(t, afero.WriteFile(fsys, testPath, []byte("-- TODO: test profiles\nselect * from posts; /* profiles */"), 0644))
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(LIST_POLICIES, []string{"public"}).
+			Reply("SELECT 2",
+				[]interface{}{"public", "posts", "Authors can delete posts", "DELETE"},
+				[]interface{}{"public", "profiles", "Users can view own profile", "SELECT"},
+			)
+		// Connect to mock
+		ctx := context.Background()
+		mock, err := utils.ConnectByConfig(ctx, dbConfig, conn.Intercept)
+		require.NoError(t, err)
+		defer mock.Close(ctx)
+		// Run test
+		cov := Coverage{schemas: []string{"public"}}
+		err = cov.listPolicies(ctx, mock, []string{testPath}, fsys)
+		// Check error
+		assert.NoError(t, err)
+		require.Len(t, cov.Policies, 2)
+		assert.True(t, cov.Policies[0].Referenced)
+		assert.False(t, cov.Policies[1].Referenced)
+	})
+
+	t.Run("counts statement lines from source", func(t *testing.T) {
+		source := `
+declare
+  total int := 0;
+begin
+  -- sum all rows
+  if i > 0 then
+    total := i;
+  else
+    raise exception 'invalid';
+  end if;
+
+  return total;
+exception
+  when others then
+    return 0;
+end
+`
+		assert.Equal(t, []int{4, 6, 7, 9, 12, 14, 15}, statementLines(source))
+	})
+
+	t.Run("encodes cobertura report", func(t *testing.T) {
+		cov := Coverage{Functions: []FunctionCoverage{{
+			Name:  "private.check(uuid)",
+			Lines: []LineCoverage{{Line: 1, Hits: 2}},
+		}}}
+		var out strings.Builder
+		// Run test
+		err := cov.Encode(OutputCobertura, &out)
+		// Check error
+		assert.NoError(t, err)
+		assert.Contains(t, out.String(), `<package name="private" line-rate="1.0000">`)
+		assert.Contains(t, out.String(), `<class name="private.check(uuid)" filename="private.check(uuid)" line-rate="1.0000">`)
+		assert.Contains(t, out.String(), `<line number="1" hits="2"></line>`)
 	})
 }
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/db/reset"
+	"github.com/supabase/cli/internal/utils"
+)
+
+// Watch runs all tests on start, then re-runs tests affected by changes to local files until cancelled.
+func Watch(ctx context.Context, testFiles []string, jobs uint, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	if len(testFiles) == 0 {
+		testFiles = append(testFiles, utils.DbTestsDir)
+	}
+	files, err := listTestFiles(testFiles, fsys)
+	if err != nil {
+		return err
+	}
+	return utils.RunProgram(ctx, func(p utils.Program, ctx context.Context) error {
+		runWatchedTests(ctx, p, files, jobs, config, fsys, options...)
+		return utils.WatchFiles(ctx, append(testFiles, reset.WatchPaths...), func(changed []string) error {
+			files, err := listTestFiles(testFiles, fsys)
+			if err != nil {
+				reset.SendWatchStatus(p, "", err)
+				return nil
+			}
+			affected, schema := filterChanged(files, changed)
+			if len(schema) > 0 {
+				// Only the local database is migrated automatically
+				if utils.IsLocalDatabase(config) {
+					p.Send(utils.StatusMsg("Applying changes..."))
+					if _, err := reset.ApplyChanges(ctx, schema, fsys, options...); err != nil {
+						reset.SendWatchStatus(p, "", err)
+						return nil
+					}
+				}
+				affected = files
+			}
+			if len(affected) > 0 {
+				runWatchedTests(ctx, p, affected, jobs, config, fsys, options...)
+			}
+			return nil
+		})
+	})
+}
+
+// Splits changed files into test files to re-run and schema files that affect all tests.
+func filterChanged(files, changed []string) (affected, schema []string) {
+	tests := make(map[string]struct{}, len(files))
+	for _, fp := range files {
+		tests[filepath.Clean(fp)] = struct{}{}
+	}
+	for _, path := range changed {
+		if _, ok := tests[filepath.Clean(path)]; ok {
+			affected = append(affected, path)
+		} else if filepath.Dir(path) == utils.MigrationsDir || path == utils.SeedDataPath {
+			schema = append(schema, path)
+		}
+	}
+	return affected, schema
+}
+
+func runWatchedTests(ctx context.Context, p utils.Program, files []string, jobs uint, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) {
+	p.Send(utils.StatusMsg(fmt.Sprintf("Running %d test files...", len(files))))
+	report, _, err := runSuite(ctx, files, jobs, false, config, fsys, options...)
+	// Show failed tests below the status line
+	p.Send(utils.PsqlMsg(nil))
+	for _, f := range report.Files {
+		for _, c := range f.Cases {
+			if !c.Passed() {
+				line := fmt.Sprintf("%s %s: %d - %s", utils.Red("✘"), f.Name, c.Number, c.Description)
+				p.Send(utils.PsqlMsg(&line))
+			}
+		}
+		if len(f.Error) > 0 {
+			line := fmt.Sprintf("%s %s: %s", utils.Red("✘"), f.Name, f.Error)
+			p.Send(utils.PsqlMsg(&line))
+		}
+	}
+	tests, failures := report.countTests()
+	summary := fmt.Sprintf("Files=%d, Tests=%d, Failures=%d, %s", len(report.Files), tests, failures, formatDuration(report.Duration))
+	if err == nil && !report.Passed() {
+		err = errors.Errorf("%d of %d tests failed. %s", failures, tests, summary)
+	}
+	reset.SendWatchStatus(p, summary, err)
+}
@@ -2,13 +2,12 @@ package bloat
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/go-errors/errors"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/spf13/afero"
-	"github.com/supabase/cli/internal/migration/list"
+	"github.com/supabase/cli/internal/inspect"
 	"github.com/supabase/cli/internal/utils"
 	"github.com/supabase/cli/internal/utils/pgxv5"
 )
@@ -76,30 +75,29 @@ FROM
 ORDER BY raw_waste DESC, bloat DESC`
 
 type Result struct {
-	Type        string
-	Schemaname  string
-	Object_name string
-	Bloat       string
-	Waste       string
+	Type        string `json:"type" title:"Type"`
+	Schemaname  string `json:"schemaname" title:"Schema name"`
+	Object_name string `json:"object_name" title:"Object name"`
+	Bloat       string `json:"bloat" title:"Bloat"`
+	Waste       string `json:"waste" title:"Waste"`
 }
 
-func Run(ctx context.Context, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+func Run(ctx context.Context, output string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
 	conn, err := utils.ConnectByConfig(ctx, config, options...)
 	if err != nil {
 		return err
 	}
-	rows, err := conn.Query(ctx, QUERY)
-	if err != nil {
-		return errors.Errorf("failed to query rows: %w", err)
-	}
-	result, err := pgxv5.CollectRows[Result](rows)
+	result, err := Query(ctx, conn)
 	if err != nil {
 		return err
 	}
+	return inspect.Render(output, result)
+}
 
-	table := "|Type|Schema name|Object name|Bloat|Waste\n|-|-|-|-|-|\n"
-	for _, r := range result {
-		table += fmt.Sprintf("|`%s`|`%s`|`%s`|`%s`|`%s`|\n", r.Type, r.Schemaname, r.Object_name, r.Bloat, r.Waste)
+func Query(ctx context.Context, conn *pgx.Conn) ([]Result, error) {
+	rows, err := conn.Query(ctx, QUERY)
+	if err != nil {
+		return nil, errors.Errorf("failed to query rows: %w", err)
 	}
-	return list.RenderTable(table)
+	return pgxv5.CollectRows[Result](rows)
 }
@@ -2,14 +2,12 @@ package blocking
 
 import (
 	"context"
-	"fmt"
-	"regexp"
 
 	"github.com/go-errors/errors"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/spf13/afero"
-	"github.com/supabase/cli/internal/migration/list"
+	"github.com/supabase/cli/internal/inspect"
 	"github.com/supabase/cli/internal/utils"
 	"github.com/supabase/cli/internal/utils/pgxv5"
 )
@@ -34,40 +32,30 @@ WHERE NOT bl.granted
 `
 
 type Result struct {
-	Blocked_pid        string
-	Blocking_statement string
-	Blocking_duration  string
-	Blocking_pid       string
-	Blocked_statement  string
-	Blocked_duration   string
+	Blocked_pid        string `json:"blocked_pid" title:"blocked pid"`
+	Blocking_statement string `json:"blocking_statement" title:"blocking statement"`
+	Blocking_duration  string `json:"blocking_duration" title:"blocking duration"`
+	Blocking_pid       string `json:"blocking_pid" title:"blocking pid"`
+	Blocked_statement  string `json:"blocked_statement" title:"blocked statement"`
+	Blocked_duration   string `json:"blocked_duration" title:"blocked duration"`
 }
 
-func Run(ctx context.Context, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+func Run(ctx context.Context, output string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
 	conn, err := utils.ConnectByConfig(ctx, config, options...)
 	if err != nil {
 		return err
 	}
-	rows, err := conn.Query(ctx, QUERY)
-	if err != nil {
-		return errors.Errorf("failed to query rows: %w", err)
-	}
-	result, err := pgxv5.CollectRows[Result](rows)
+	result, err := Query(ctx, conn)
 	if err != nil {
 		return err
 	}
+	return inspect.Render(output, result)
+}
 
-	table := "|blocked pid|blocking statement|blocking duration|blocking pid|blocked statement|blocked duration|\n|-|-|-|-|-|-|\n"
-	for _, r := range result {
-		// remove whitespace from query
-		re := regexp.MustCompile(`\s+|\r+|\n+|\t+|\v`)
-		blocking_statement := re.ReplaceAllString(r.Blocking_statement, " ")
-		blocked_statement := re.ReplaceAllString(r.Blocked_statement, " ")
-
-		// escape pipes in query
-		re = regexp.MustCompile(`\|`)
-		blocking_statement = re.ReplaceAllString(blocking_statement, `\|`)
-		blocked_statement = re.ReplaceAllString(blocked_statement, `\|`)
-		table += fmt.Sprintf("|`%v`|`%v`|`%v`|`%v`|%s|`%v`|\n", r.Blocked_pid, blocking_statement, r.Blocking_duration, r.Blocking_pid, blocked_statement, r.Blocked_duration)
+func Query(ctx context.Context, conn *pgx.Conn) ([]Result, error) {
+	rows, err := conn.Query(ctx, QUERY)
+	if err != nil {
+		return nil, errors.Errorf("failed to query rows: %w", err)
 	}
-	return list.RenderTable(table)
+	return pgxv5.CollectRows[Result](rows)
 }
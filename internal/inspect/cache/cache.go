@@ -2,13 +2,12 @@ package cache
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/go-errors/errors"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/spf13/afero"
-	"github.com/supabase/cli/internal/migration/list"
+	"github.com/supabase/cli/internal/inspect"
 	"github.com/supabase/cli/internal/utils"
 	"github.com/supabase/cli/internal/utils/pgxv5"
 )
@@ -27,27 +26,26 @@ FROM pg_statio_user_tables;
 `
 
 type Result struct {
-	Name  string
-	Ratio float64
+	Name  string  `json:"name" title:"Name"`
+	Ratio float64 `json:"ratio" title:"Ratio"`
 }
 
-func Run(ctx context.Context, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+func Run(ctx context.Context, output string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
 	conn, err := utils.ConnectByConfig(ctx, config, options...)
 	if err != nil {
 		return err
 	}
-	rows, err := conn.Query(ctx, QUERY)
-	if err != nil {
-		return errors.Errorf("failed to query rows: %w", err)
-	}
-	result, err := pgxv5.CollectRows[Result](rows)
+	result, err := Query(ctx, conn)
 	if err != nil {
 		return err
 	}
-	// TODO: implement a markdown table marshaller
-	table := "|Name|Ratio|\n|-|-|\n"
-	for _, r := range result {
-		table += fmt.Sprintf("|`%s`|`%.6f`|\n", r.Name, r.Ratio)
+	return inspect.Render(output, result)
+}
+
+func Query(ctx context.Context, conn *pgx.Conn) ([]Result, error) {
+	rows, err := conn.Query(ctx, QUERY)
+	if err != nil {
+		return nil, errors.Errorf("failed to query rows: %w", err)
 	}
-	return list.RenderTable(table)
+	return pgxv5.CollectRows[Result](rows)
 }
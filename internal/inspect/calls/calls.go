@@ -2,14 +2,12 @@ package calls
 
 import (
 	"context"
-	"fmt"
-	"regexp"
 
 	"github.com/go-errors/errors"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/spf13/afero"
-	"github.com/supabase/cli/internal/migration/list"
+	"github.com/supabase/cli/internal/inspect"
 	"github.com/supabase/cli/internal/utils"
 	"github.com/supabase/cli/internal/utils/pgxv5"
 )
@@ -27,37 +25,29 @@ LIMIT 10
 `
 
 type Result struct {
-	Total_exec_time string
-	Prop_exec_time  string
-	Ncalls          string
-	Sync_io_time    string
-	Query           string
+	Query           string `json:"query" title:"Query"`
+	Total_exec_time string `json:"total_exec_time" title:"Total Execution Time"`
+	Prop_exec_time  string `json:"prop_exec_time" title:"Proportion of total exec time"`
+	Ncalls          string `json:"ncalls" title:"Number Calls"`
+	Sync_io_time    string `json:"sync_io_time" title:"Sync IO time"`
 }
 
-func Run(ctx context.Context, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+func Run(ctx context.Context, output string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
 	conn, err := utils.ConnectByConfig(ctx, config, options...)
 	if err != nil {
 		return err
 	}
-	rows, err := conn.Query(ctx, QUERY)
-	if err != nil {
-		return errors.Errorf("failed to query rows: %w", err)
-	}
-	result, err := pgxv5.CollectRows[Result](rows)
+	result, err := Query(ctx, conn)
 	if err != nil {
 		return err
 	}
-	// TODO: implement a markdown table marshaller
-	table := "|Query|Total Execution Time|Proportion of total exec time|Number Calls|Sync IO time|\n|-|-|-|-|-|\n"
-	for _, r := range result {
-		// remove whitespace from query
-		re := regexp.MustCompile(`\s+|\r+|\n+|\t+|\v`)
-		query := re.ReplaceAllString(r.Query, " ")
+	return inspect.Render(output, result)
+}
 
-		// escape pipes in query
-		re = regexp.MustCompile(`\|`)
-		query = re.ReplaceAllString(query, `\|`)
-		table += fmt.Sprintf("|`%s`|`%s`|`%s`|`%s`|`%s`|\n", query, r.Total_exec_time, r.Prop_exec_time, r.Ncalls, r.Sync_io_time)
+func Query(ctx context.Context, conn *pgx.Conn) ([]Result, error) {
+	rows, err := conn.Query(ctx, QUERY)
+	if err != nil {
+		return nil, errors.Errorf("failed to query rows: %w", err)
 	}
-	return list.RenderTable(table)
+	return pgxv5.CollectRows[Result](rows)
 }
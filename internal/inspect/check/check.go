@@ -0,0 +1,209 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/inspect"
+	"github.com/supabase/cli/internal/inspect/bloat"
+	"github.com/supabase/cli/internal/inspect/cache"
+	"github.com/supabase/cli/internal/inspect/replication_slots"
+	"github.com/supabase/cli/internal/inspect/unused_indexes"
+	"github.com/supabase/cli/internal/inspect/vacuum_stats"
+	"github.com/supabase/cli/internal/utils"
+)
+
+// Rules are thresholds for inspection results. Setting a rule to 0 disables it.
+type Rules struct {
+	MaxBloatRatio      float64      `toml:"max_bloat_ratio"`
+	MinCacheHitRatio   float64      `toml:"min_cache_hit_ratio"`
+	MaxUnusedIndexSize inspect.Size `toml:"max_unused_index_size"`
+	MaxDeadTuples      int64        `toml:"max_dead_tuples"`
+	MaxReplicationLag  inspect.Size `toml:"max_replication_lag"`
+}
+
+func DefaultRules() Rules {
+	return Rules{
+		MaxBloatRatio:      2,
+		MinCacheHitRatio:   0.99,
+		MaxUnusedIndexSize: 100 << 20,
+		MaxDeadTuples:      1000000,
+		MaxReplicationLag:  1 << 30,
+	}
+}
+
+// LoadRules reads thresholds from path, defaulting to the project's inspect.toml if it exists.
+func LoadRules(path string, fsys afero.Fs) (Rules, error) {
+	rules := DefaultRules()
+	if len(path) == 0 {
+		path = utils.InspectRulesPath
+	}
+	// Rules not set in the file keep their default values
+	md, err := toml.DecodeFS(afero.NewIOFS(fsys), path, &rules)
+	if errors.Is(err, os.ErrNotExist) && path == utils.InspectRulesPath {
+		return rules, nil
+	} else if err != nil {
+		return rules, errors.Errorf("failed to parse inspect rules: %w", err)
+	}
+	// Misspelled rules would otherwise be silently ignored
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, k := range undecoded {
+			keys[i] = k.String()
+		}
+		return rules, errors.Errorf("Unknown inspect rules in %s: %s", utils.Bold(path), strings.Join(keys, ", "))
+	}
+	return rules, nil
+}
+
+type Violation struct {
+	Rule    string `json:"rule" title:"Rule"`
+	Object  string `json:"object" title:"Object"`
+	Message string `json:"message" title:"Message"`
+}
+
+func (v Violation) String() string {
+	return v.Object + " " + v.Message + "."
+}
+
+func (r Rules) CacheHit(row cache.Result) []Violation {
+	if r.MinCacheHitRatio > 0 && row.Ratio < r.MinCacheHitRatio {
+		return []Violation{{
+			Rule:    "min_cache_hit_ratio",
+			Object:  row.Name,
+			Message: fmt.Sprintf("is %.2f%%, below %.2f%%", row.Ratio*100, r.MinCacheHitRatio*100),
+		}}
+	}
+	return nil
+}
+
+func (r Rules) Bloat(row bloat.Result) []Violation {
+	if ratio, err := strconv.ParseFloat(row.Bloat, 64); err == nil && r.MaxBloatRatio > 0 && ratio > r.MaxBloatRatio {
+		return []Violation{{
+			Rule:    "max_bloat_ratio",
+			Object:  row.Schemaname + "." + row.Object_name,
+			Message: fmt.Sprintf("is bloated by %sx, above %gx, wasting %s", row.Bloat, r.MaxBloatRatio, row.Waste),
+		}}
+	}
+	return nil
+}
+
+func (r Rules) UnusedIndex(row unused_indexes.Result) []Violation {
+	if size, err := inspect.ParseSize(row.Index_size); err == nil && r.MaxUnusedIndexSize > 0 && inspect.Size(size) > r.MaxUnusedIndexSize {
+		return []Violation{{
+			Rule:    "max_unused_index_size",
+			Object:  row.Index,
+			Message: fmt.Sprintf("on %s is rarely used but takes up %s, above %s", row.Table, row.Index_size, r.MaxUnusedIndexSize),
+		}}
+	}
+	return nil
+}
+
+var nonDigitPattern = regexp.MustCompile(`\D`)
+
+func (r Rules) DeadTuples(row vacuum_stats.Result) []Violation {
+	// Counts are formatted with locale specific group separators
+	count, err := strconv.ParseInt(nonDigitPattern.ReplaceAllString(row.Dead_rowcount, ""), 10, 64)
+	if err == nil && r.MaxDeadTuples > 0 && count > r.MaxDeadTuples {
+		return []Violation{{
+			Rule:    "max_dead_tuples",
+			Object:  row.Schema + "." + row.Table,
+			Message: fmt.Sprintf("has %d dead tuples, above %d", count, r.MaxDeadTuples),
+		}}
+	}
+	return nil
+}
+
+func (r Rules) ReplicationLag(row replication_slots.Result) []Violation {
+	lag, err := strconv.ParseFloat(strings.TrimSpace(row.Replication_lag_gb), 64)
+	if size := inspect.Size(lag * (1 << 30)); err == nil && r.MaxReplicationLag > 0 && size > r.MaxReplicationLag {
+		return []Violation{{
+			Rule:    "max_replication_lag",
+			Object:  row.Slot_name,
+			Message: fmt.Sprintf("is lagging by %s, above %s", size, r.MaxReplicationLag),
+		}}
+	}
+	return nil
+}
+
+func collect[T any](ctx context.Context, conn *pgx.Conn, query func(context.Context, *pgx.Conn) ([]T, error), check func(T) []Violation) ([]Violation, error) {
+	rows, err := query(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	var result []Violation
+	for _, r := range rows {
+		result = append(result, check(r)...)
+	}
+	return result, nil
+}
+
+// Evaluate runs the inspections covered by rules, returning all violations.
+func (r Rules) Evaluate(ctx context.Context, conn *pgx.Conn) ([]Violation, error) {
+	result := []Violation{}
+	for _, run := range []func() ([]Violation, error){
+		func() ([]Violation, error) { return collect(ctx, conn, bloat.Query, r.Bloat) },
+		func() ([]Violation, error) { return collect(ctx, conn, cache.Query, r.CacheHit) },
+		func() ([]Violation, error) { return collect(ctx, conn, unused_indexes.Query, r.UnusedIndex) },
+		func() ([]Violation, error) { return collect(ctx, conn, vacuum_stats.Query, r.DeadTuples) },
+		func() ([]Violation, error) { return collect(ctx, conn, replication_slots.Query, r.ReplicationLag) },
+	} {
+		violations, err := run()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, violations...)
+	}
+	return result, nil
+}
+
+func Run(ctx context.Context, output, rulesPath string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	rules, err := LoadRules(rulesPath, fsys)
+	if err != nil {
+		return err
+	}
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	result, err := rules.Evaluate(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if len(result) == 0 {
+		fmt.Fprintln(os.Stderr, "All inspection checks passed.")
+		return nil
+	}
+	if err := inspect.Render(output, result); err != nil {
+		return err
+	}
+	return errors.Errorf("Found %d violations: %s", len(result), summarise(result))
+}
+
+// Counts violations by rule, ie. max_bloat_ratio (2), min_cache_hit_ratio (1)
+func summarise(violations []Violation) string {
+	counts := map[string]int{}
+	for _, v := range violations {
+		counts[v.Rule]++
+	}
+	rules := make([]string, 0, len(counts))
+	for rule := range counts {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	for i, rule := range rules {
+		rules[i] = fmt.Sprintf("%s (%d)", rule, counts[rule])
+	}
+	return strings.Join(rules, ", ")
+}
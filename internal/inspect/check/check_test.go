@@ -0,0 +1,152 @@
+package check
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/inspect"
+	"github.com/supabase/cli/internal/inspect/bloat"
+	"github.com/supabase/cli/internal/inspect/cache"
+	"github.com/supabase/cli/internal/inspect/replication_slots"
+	"github.com/supabase/cli/internal/inspect/vacuum_stats"
+	"github.com/supabase/cli/internal/utils"
+)
+
+func TestLoadRules(t *testing.T) {
+	t.Run("defaults without config file", func(t *testing.T) {
+		rules, err := LoadRules("", afero.NewMemMapFs())
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultRules(), rules)
+	})
+
+	t.Run("overrides default rules", func(t *testing.T) {
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, utils.InspectRulesPath, []byte(`
+max_bloat_ratio = 0
+max_unused_index_size = "1GB"
+`), 0644))
+		rules, err := LoadRules("", fsys)
+		assert.NoError(t, err)
+		expected := DefaultRules()
+		expected.MaxBloatRatio = 0
+		expected.MaxUnusedIndexSize = 1 << 30
+		assert.Equal(t, expected, rules)
+	})
+
+	t.Run("throws error on unknown rule", func(t *testing.T) {
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, "rules.toml", []byte(`max_bloat = 3`), 0644))
+		_, err := LoadRules("rules.toml", fsys)
+		assert.ErrorContains(t, err, "max_bloat")
+	})
+
+	t.Run("throws error on missing file", func(t *testing.T) {
+		_, err := LoadRules("rules.toml", afero.NewMemMapFs())
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestBloat(t *testing.T) {
+	row := bloat.Result{Schemaname: "public", Object_name: "users", Waste: "1 GB"}
+	cases := []struct {
+		name     string
+		bloat    string
+		max      float64
+		expected int
+	}{
+		{"above threshold", "2.5", 2, 1},
+		{"below threshold", "1.5", 2, 0},
+		{"equal to threshold", "2.0", 2, 0},
+		{"ignores invalid ratio", "n/a", 2, 0},
+		{"disabled by zero", "9.9", 0, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			row.Bloat = c.bloat
+			result := Rules{MaxBloatRatio: c.max}.Bloat(row)
+			assert.Len(t, result, c.expected)
+			for _, v := range result {
+				assert.Equal(t, "public.users", v.Object)
+				assert.Equal(t, "is bloated by 2.5x, above 2x, wasting 1 GB", v.Message)
+			}
+		})
+	}
+}
+
+func TestCacheHit(t *testing.T) {
+	row := cache.Result{Name: "table hit rate", Ratio: 0.5}
+	assert.Len(t, Rules{MinCacheHitRatio: 0.99}.CacheHit(row), 1)
+	assert.Empty(t, Rules{MinCacheHitRatio: 0.5}.CacheHit(row))
+	assert.Empty(t, Rules{}.CacheHit(row))
+}
+
+func TestDeadTuples(t *testing.T) {
+	cases := []struct {
+		name     string
+		count    string
+		expected int
+	}{
+		{"comma separators", "1,234,567", 1},
+		{"period separators", "1.234.567", 1},
+		{"space separators", "1 234 567", 1},
+		{"below threshold", "999,999", 0},
+		{"ignores empty count", "", 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			row := vacuum_stats.Result{Schema: "public", Table: "users", Dead_rowcount: c.count}
+			result := Rules{MaxDeadTuples: 1000000}.DeadTuples(row)
+			assert.Len(t, result, c.expected)
+			for _, v := range result {
+				assert.Equal(t, "has 1234567 dead tuples, above 1000000", v.Message)
+			}
+		})
+	}
+}
+
+func TestReplicationLag(t *testing.T) {
+	cases := []struct {
+		name     string
+		lag      string
+		max      inspect.Size
+		expected []Violation
+	}{
+		{"converts gigabytes to bytes", " 1.5", 1 << 30, []Violation{{
+			Rule:    "max_replication_lag",
+			Object:  "slot",
+			Message: "is lagging by " + inspect.Size(3<<29).String() + ", above " + inspect.Size(1<<30).String(),
+		}}},
+		{"below threshold", "0.5", 1 << 30, nil},
+		{"ignores invalid lag", "", 1 << 30, nil},
+		{"disabled by zero", "1.5", 0, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			row := replication_slots.Result{Slot_name: "slot", Replication_lag_gb: c.lag}
+			assert.Equal(t, c.expected, Rules{MaxReplicationLag: c.max}.ReplicationLag(row))
+		})
+	}
+}
+
+func TestSummarise(t *testing.T) {
+	cases := []struct {
+		name       string
+		violations []Violation
+		expected   string
+	}{
+		{"no violations", nil, ""},
+		{"counts by rule in sorted order", []Violation{
+			{Rule: "min_cache_hit_ratio"},
+			{Rule: "max_bloat_ratio"},
+			{Rule: "max_bloat_ratio"},
+		}, "max_bloat_ratio (2), min_cache_hit_ratio (1)"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, summarise(c.violations))
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package custom
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/inspect"
+	"github.com/supabase/cli/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Query is a user defined inspect query, loaded from a SQL file with a front matter of
+// leading line comments in YAML format, enclosed by delimiters, ie.
+//
+//	-- ---
+//	-- title: Tables without a primary key
+//	-- columns:
+//	--   table_name: Table
+//	--   oid: "-"
+//	-- ---
+//	select ...
+type Query struct {
+	Name string `yaml:"-"`
+	// Short description of the command
+	Title string `yaml:"title"`
+	// Titles of result columns in pretty output
+	Columns map[string]string `yaml:"columns"`
+	SQL     string            `yaml:"-"`
+}
+
+const frontMatterDelimiter = "---"
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Parse reads the front matter of a query file, named after its base file name. Leading
+// comments without a delimiter are plain comments and ignored.
+func Parse(name string, data []byte) (Query, error) {
+	query := Query{Name: name, Title: defaultTitle(name), SQL: strings.TrimSpace(string(data))}
+	lines := strings.Split(query.SQL, "\n")
+	if comment, ok := cutComment(lines[0]); !ok || comment != frontMatterDelimiter {
+		return query, nil
+	}
+	var front []string
+	for _, line := range lines[1:] {
+		comment, ok := cutComment(line)
+		if !ok {
+			break
+		}
+		if comment != frontMatterDelimiter {
+			front = append(front, comment)
+			continue
+		}
+		if err := yaml.Unmarshal([]byte(strings.Join(front, "\n")), &query); err != nil {
+			query.Title = defaultTitle(name)
+			return query, errors.Errorf("failed to parse front matter of %s: %w", name, err)
+		}
+		if len(query.Title) == 0 {
+			query.Title = defaultTitle(name)
+		}
+		return query, nil
+	}
+	return query, errors.Errorf("failed to parse front matter of %s: missing closing -- %s", name, frontMatterDelimiter)
+}
+
+func cutComment(line string) (string, bool) {
+	comment, ok := strings.CutPrefix(strings.TrimRight(line, "\r"), "--")
+	if !ok {
+		return "", false
+	}
+	// Indentation after the first space is significant in YAML
+	comment = strings.TrimPrefix(comment, " ")
+	if strings.TrimSpace(comment) == frontMatterDelimiter {
+		return frontMatterDelimiter, true
+	}
+	return comment, true
+}
+
+func defaultTitle(name string) string {
+	return "Run the custom query in " + name + ".sql"
+}
+
+// List returns the names of query files in the project that are valid command names.
+func List(fsys afero.Fs) ([]string, error) {
+	files, err := afero.ReadDir(fsys, utils.InspectQueriesDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Errorf("failed to read inspect queries: %w", err)
+	}
+	var result []string
+	for _, f := range files {
+		name, ok := strings.CutSuffix(f.Name(), ".sql")
+		if !f.IsDir() && ok && namePattern.MatchString(name) {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func Load(name string, fsys afero.Fs) (Query, error) {
+	data, err := afero.ReadFile(fsys, filepath.Join(utils.InspectQueriesDir, name+".sql"))
+	if err != nil {
+		return Query{Name: name, Title: defaultTitle(name)}, errors.Errorf("failed to read inspect query: %w", err)
+	}
+	return Parse(name, data)
+}
+
+func Run(ctx context.Context, output, name string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	query, err := Load(name, fsys)
+	if err != nil {
+		return err
+	}
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	columns, rows, err := query.Exec(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return inspect.RenderRows(output, columns, rows)
+}
+
+// Exec returns all values as text, the same as psql, because column types are not known in advance.
+func (q Query) Exec(ctx context.Context, conn *pgx.Conn) ([]inspect.Column, [][]*string, error) {
+	// Simple protocol returns values in text format
+	rows, err := conn.Query(ctx, q.SQL, pgx.QuerySimpleProtocol(true))
+	if err != nil {
+		return nil, nil, errors.Errorf("failed to query rows: %w", err)
+	}
+	defer rows.Close()
+	var columns []inspect.Column
+	for _, f := range rows.FieldDescriptions() {
+		c := inspect.Column{Key: string(f.Name), Title: string(f.Name)}
+		if title, ok := q.Columns[c.Key]; ok {
+			c.Title = title
+		}
+		columns = append(columns, c)
+	}
+	var result [][]*string
+	for rows.Next() {
+		values := rows.RawValues()
+		cells := make([]*string, len(values))
+		for i, v := range values {
+			if v != nil {
+				cell := string(v)
+				cells[i] = &cell
+			}
+		}
+		result = append(result, cells)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, errors.Errorf("failed to parse rows: %w", err)
+	}
+	return columns, result, nil
+}
@@ -0,0 +1,68 @@
+package custom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("parses front matter", func(t *testing.T) {
+		sql := `-- ---
+-- title: Tables without a primary key
+-- columns:
+--   table_name: Table
+--   oid: "-"
+-- ---
+-- plain comment after front matter
+select table_name, oid from tables;
+-- trailing comment is not front matter
+`
+		query, err := Parse("no-pk", []byte(sql))
+		assert.NoError(t, err)
+		assert.Equal(t, "no-pk", query.Name)
+		assert.Equal(t, "Tables without a primary key", query.Title)
+		assert.Equal(t, map[string]string{"table_name": "Table", "oid": "-"}, query.Columns)
+		assert.Contains(t, query.SQL, "select table_name")
+	})
+
+	t.Run("parses front matter with CRLF", func(t *testing.T) {
+		sql := "-- ---\r\n-- title: Row counts\r\n-- columns:\r\n--   n: Count\r\n-- ---\r\nselect 1 as n;\r\n"
+		query, err := Parse("rows", []byte(sql))
+		assert.NoError(t, err)
+		assert.Equal(t, "Row counts", query.Title)
+		assert.Equal(t, map[string]string{"n": "Count"}, query.Columns)
+	})
+
+	t.Run("defaults title without front matter", func(t *testing.T) {
+		query, err := Parse("rows", []byte("select 1"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Run the custom query in rows.sql", query.Title)
+		assert.Empty(t, query.Columns)
+		assert.Equal(t, "select 1", query.SQL)
+	})
+
+	t.Run("ignores comments without delimiter", func(t *testing.T) {
+		for _, sql := range []string{
+			"-- Lists every row\nselect 1",
+			"-- title: Counts: all rows\nselect 1",
+		} {
+			query, err := Parse("rows", []byte(sql))
+			assert.NoError(t, err)
+			assert.Equal(t, "Run the custom query in rows.sql", query.Title)
+			assert.Empty(t, query.Columns)
+		}
+	})
+
+	t.Run("throws error on missing closing delimiter", func(t *testing.T) {
+		query, err := Parse("rows", []byte("-- ---\n-- title: Row counts\nselect 1"))
+		assert.ErrorContains(t, err, "missing closing -- ---")
+		assert.Equal(t, "Run the custom query in rows.sql", query.Title)
+	})
+
+	t.Run("throws error on invalid yaml", func(t *testing.T) {
+		query, err := Parse("rows", []byte("-- ---\n-- title: [unclosed\n-- ---\nselect 1"))
+		assert.ErrorContains(t, err, "failed to parse front matter of rows")
+		assert.Equal(t, "Run the custom query in rows.sql", query.Title)
+	})
+}
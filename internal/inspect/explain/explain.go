@@ -0,0 +1,104 @@
+package explain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/inspect"
+	"github.com/supabase/cli/internal/utils"
+	"github.com/supabase/cli/internal/utils/parser"
+)
+
+// Same settings as PostgREST, which are read by auth.uid() and auth.jwt()
+const SET_CLAIMS = "SELECT set_config('request.jwt.claims', $1, true), set_config('request.jwt.claim.sub', coalesce($1::json->>'sub', ''), true)"
+
+func Run(ctx context.Context, output, file string, analyze bool, role, claims string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	if output == inspect.OutputCsv {
+		return errors.New("query plan cannot be encoded as csv")
+	}
+	query, err := readQuery(file, fsys)
+	if err != nil {
+		return err
+	}
+	if len(claims) > 0 && !json.Valid([]byte(claims)) {
+		return errors.Errorf("invalid JWT claims: %s", claims)
+	}
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	data, err := Explain(ctx, query, analyze, role, claims, conn)
+	if err != nil {
+		return err
+	}
+	if output != utils.OutputPretty {
+		var value any
+		if err := json.Unmarshal(data, &value); err != nil {
+			return errors.Errorf("failed to parse query plan: %w", err)
+		}
+		return utils.EncodeOutput(output, os.Stdout, value)
+	}
+	result, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	RenderTree(os.Stdout, result, analyze)
+	return nil
+}
+
+// Only a single statement can be explained, which also prevents a file from committing
+// the transaction that analyzed statements are rolled back in.
+func readQuery(file string, fsys afero.Fs) (string, error) {
+	sql, err := fsys.Open(file)
+	if err != nil {
+		return "", errors.Errorf("failed to open query file: %w", err)
+	}
+	defer sql.Close()
+	stats, err := parser.SplitAndTrim(sql)
+	if err != nil {
+		return "", err
+	}
+	if len(stats) != 1 {
+		return "", errors.Errorf("Expected exactly one statement in %s, found %d.", utils.Bold(file), len(stats))
+	}
+	return stats[0], nil
+}
+
+// Explain plans the query as role with the given JWT claims, so that RLS policies are applied the
+// same way as requests through the Data API. Statements executed with analyze are always rolled back,
+// so sql must be a single statement.
+func Explain(ctx context.Context, sql string, analyze bool, role, claims string, conn *pgx.Conn) ([]byte, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, errors.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(context.Background()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+	if len(claims) > 0 {
+		if _, err := tx.Exec(ctx, SET_CLAIMS, claims); err != nil {
+			return nil, errors.Errorf("failed to set JWT claims: %w", err)
+		}
+	}
+	if len(role) > 0 {
+		if _, err := tx.Exec(ctx, "SET LOCAL ROLE "+pgx.Identifier{role}.Sanitize()); err != nil {
+			return nil, errors.Errorf("failed to set role: %w", err)
+		}
+	}
+	query := fmt.Sprintf("EXPLAIN (ANALYZE %t, BUFFERS, FORMAT JSON) %s", analyze, sql)
+	var data []byte
+	// Use simple protocol so that queries without parameters are not prepared
+	if err := tx.QueryRow(ctx, query, pgx.QuerySimpleProtocol(true)).Scan(&data); err != nil {
+		return nil, errors.Errorf("failed to explain query: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,38 @@
+package explain
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadQuery(t *testing.T) {
+	t.Run("trims single statement", func(t *testing.T) {
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, "query.sql", []byte("\nselect * from users;\n"), 0644))
+		query, err := readQuery("query.sql", fsys)
+		assert.NoError(t, err)
+		assert.Equal(t, "select * from users", query)
+	})
+
+	t.Run("throws error on multiple statements", func(t *testing.T) {
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, "query.sql", []byte("delete from users; commit;"), 0644))
+		_, err := readQuery("query.sql", fsys)
+		assert.ErrorContains(t, err, "found 2")
+	})
+
+	t.Run("throws error on empty file", func(t *testing.T) {
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, "query.sql", []byte("\n  \n"), 0644))
+		_, err := readQuery("query.sql", fsys)
+		assert.ErrorContains(t, err, "found 0")
+	})
+
+	t.Run("throws error on missing file", func(t *testing.T) {
+		_, err := readQuery("query.sql", afero.NewMemMapFs())
+		assert.ErrorContains(t, err, "failed to open query file")
+	})
+}
@@ -0,0 +1,63 @@
+package explain
+
+import (
+	"encoding/json"
+
+	"github.com/go-errors/errors"
+)
+
+// Plan is a node of the query plan returned by EXPLAIN (FORMAT JSON).
+type Plan struct {
+	NodeType    string  `json:"Node Type"`
+	JoinType    string  `json:"Join Type"`
+	Relation    string  `json:"Relation Name"`
+	Schema      string  `json:"Schema"`
+	Alias       string  `json:"Alias"`
+	IndexName   string  `json:"Index Name"`
+	Filter      string  `json:"Filter"`
+	IndexCond   string  `json:"Index Cond"`
+	HashCond    string  `json:"Hash Cond"`
+	JoinFilter  string  `json:"Join Filter"`
+	StartupCost float64 `json:"Startup Cost"`
+	TotalCost   float64 `json:"Total Cost"`
+	PlanRows    float64 `json:"Plan Rows"`
+	// Only reported with ANALYZE option
+	ActualTotalTime     float64 `json:"Actual Total Time"`
+	ActualRows          float64 `json:"Actual Rows"`
+	ActualLoops         float64 `json:"Actual Loops"`
+	RowsRemovedByFilter float64 `json:"Rows Removed by Filter"`
+	// Only reported with BUFFERS option
+	SharedHitBlocks  int64  `json:"Shared Hit Blocks"`
+	SharedReadBlocks int64  `json:"Shared Read Blocks"`
+	Plans            []Plan `json:"Plans"`
+}
+
+// Walk visits every node of the plan tree in depth first order.
+func (p Plan) Walk(fn func(node Plan)) {
+	fn(p)
+	for _, child := range p.Plans {
+		child.Walk(fn)
+	}
+}
+
+type Result struct {
+	Plan          Plan    `json:"Plan"`
+	PlanningTime  float64 `json:"Planning Time"`
+	ExecutionTime float64 `json:"Execution Time"`
+}
+
+func Parse(data []byte) (Result, error) {
+	var result []Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return Result{}, errors.Errorf("failed to parse query plan: %w", err)
+	}
+	if len(result) == 0 {
+		return Result{}, errors.New("query plan is empty")
+	}
+	return result[0], nil
+}
+
+func ParsePlan(data []byte) (Plan, error) {
+	result, err := Parse(data)
+	return result.Plan, err
+}
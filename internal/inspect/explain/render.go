@@ -0,0 +1,148 @@
+package explain
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/supabase/cli/internal/utils"
+)
+
+const (
+	// Estimated rows that are off by more than this factor are highlighted
+	MisestimateFactor = 10
+	// Nodes taking up more than this share of the total time, or cost, are highlighted
+	ExpensiveShare = 0.3
+)
+
+// RenderTree writes the plan as an indented tree, highlighting nodes that commonly cause slow queries.
+func RenderTree(w io.Writer, result Result, analyze bool) {
+	total := result.Plan.TotalCost
+	if analyze {
+		total = result.Plan.ActualTotalTime * loops(result.Plan)
+	}
+	renderNode(w, result.Plan, "", "", total, analyze)
+	// Timings are only reported with ANALYZE option
+	if analyze {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "Planning time: %.3f ms\n", result.PlanningTime)
+		fmt.Fprintf(w, "Execution time: %.3f ms\n", result.ExecutionTime)
+	}
+}
+
+func renderNode(w io.Writer, node Plan, prefix, childPrefix string, total float64, analyze bool) {
+	line := prefix + utils.Bold(label(node))
+	line += fmt.Sprintf("  (cost=%.2f..%.2f rows=%.0f)", node.StartupCost, node.TotalCost, node.PlanRows)
+	if analyze {
+		line += fmt.Sprintf(" (actual time=%.3f rows=%.0f loops=%.0f)", node.ActualTotalTime, node.ActualRows, node.ActualLoops)
+	}
+	fmt.Fprintln(w, line)
+	// Details are indented under the node
+	detailPrefix := childPrefix + "│ "
+	if len(node.Plans) == 0 {
+		detailPrefix = childPrefix + "  "
+	}
+	for _, warning := range warnings(node, total, analyze) {
+		fmt.Fprintln(w, detailPrefix+warning)
+	}
+	for _, detail := range details(node) {
+		fmt.Fprintln(w, detailPrefix+detail)
+	}
+	for i, child := range node.Plans {
+		if i == len(node.Plans)-1 {
+			renderNode(w, child, childPrefix+"└── ", childPrefix+"    ", total, analyze)
+		} else {
+			renderNode(w, child, childPrefix+"├── ", childPrefix+"│   ", total, analyze)
+		}
+	}
+}
+
+func label(node Plan) string {
+	var sb strings.Builder
+	sb.WriteString(node.NodeType)
+	if len(node.JoinType) > 0 && node.JoinType != "Inner" {
+		sb.WriteString(" (" + node.JoinType + ")")
+	}
+	if len(node.IndexName) > 0 {
+		sb.WriteString(" using " + node.IndexName)
+	}
+	if len(node.Relation) > 0 {
+		sb.WriteString(" on ")
+		if len(node.Schema) > 0 {
+			sb.WriteString(node.Schema + ".")
+		}
+		sb.WriteString(node.Relation)
+		if len(node.Alias) > 0 && node.Alias != node.Relation {
+			sb.WriteString(" " + node.Alias)
+		}
+	}
+	return sb.String()
+}
+
+func details(node Plan) []string {
+	var result []string
+	for _, d := range []struct{ name, value string }{
+		{"Index Cond", node.IndexCond},
+		{"Hash Cond", node.HashCond},
+		{"Join Filter", node.JoinFilter},
+		{"Filter", node.Filter},
+	} {
+		if len(d.value) > 0 {
+			result = append(result, d.name+": "+d.value)
+		}
+	}
+	if node.RowsRemovedByFilter > 0 {
+		result = append(result, fmt.Sprintf("Rows Removed by Filter: %.0f", node.RowsRemovedByFilter))
+	}
+	if node.SharedHitBlocks > 0 || node.SharedReadBlocks > 0 {
+		result = append(result, fmt.Sprintf("Buffers: shared hit=%d read=%d", node.SharedHitBlocks, node.SharedReadBlocks))
+	}
+	return result
+}
+
+func warnings(node Plan, total float64, analyze bool) []string {
+	var result []string
+	if node.NodeType == "Seq Scan" {
+		warning := utils.Yellow("⚠ Sequential scan") + " on " + node.Relation
+		if len(node.Filter) > 0 {
+			warning += ", consider adding an index on the filtered columns"
+		}
+		result = append(result, warning)
+	}
+	if analyze && node.ActualLoops > 0 {
+		estimated, actual := math.Max(node.PlanRows, 1), math.Max(node.ActualRows, 1)
+		if factor := math.Max(estimated/actual, actual/estimated); factor > MisestimateFactor {
+			result = append(result, utils.Yellow("⚠ Mis-estimate")+fmt.Sprintf(" of %.0f rows is off by %.0fx, consider running ANALYZE on the table", node.PlanRows, factor))
+		}
+	}
+	if share := self(node, analyze) / total; total > 0 && share > ExpensiveShare {
+		unit := "cost"
+		if analyze {
+			unit = "time"
+		}
+		result = append(result, utils.Red("⚠ Expensive")+fmt.Sprintf(" node takes up %.0f%% of total %s", share*100, unit))
+	}
+	return result
+}
+
+// Actual times are averaged over the number of loops.
+func loops(node Plan) float64 {
+	return math.Max(node.ActualLoops, 1)
+}
+
+// Returns the time or cost of a node excluding its children.
+func self(node Plan, analyze bool) float64 {
+	value := node.TotalCost
+	if analyze {
+		value = node.ActualTotalTime * loops(node)
+	}
+	for _, child := range node.Plans {
+		if analyze {
+			value -= child.ActualTotalTime * loops(child)
+		} else {
+			value -= child.TotalCost
+		}
+	}
+	return math.Max(value, 0)
+}
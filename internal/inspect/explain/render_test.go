@@ -0,0 +1,106 @@
+package explain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Hash join where the sequential scan on users dominates the total cost and time.
+const planJson = `[{
+  "Plan": {
+    "Node Type": "Hash Join",
+    "Join Type": "Inner",
+    "Startup Cost": 10,
+    "Total Cost": 100,
+    "Plan Rows": 5,
+    "Actual Total Time": 20,
+    "Actual Rows": 500,
+    "Actual Loops": 1,
+    "Plans": [{
+      "Node Type": "Seq Scan",
+      "Relation Name": "users",
+      "Filter": "(id > 10)",
+      "Startup Cost": 0,
+      "Total Cost": 80,
+      "Plan Rows": 1000,
+      "Actual Total Time": 4,
+      "Actual Rows": 1000,
+      "Actual Loops": 4
+    }, {
+      "Node Type": "Index Scan",
+      "Relation Name": "posts",
+      "Index Name": "posts_pkey",
+      "Startup Cost": 0,
+      "Total Cost": 10,
+      "Plan Rows": 1,
+      "Actual Total Time": 0.5,
+      "Actual Rows": 1,
+      "Actual Loops": 1
+    }]
+  },
+  "Planning Time": 0.1,
+  "Execution Time": 20.5
+}]`
+
+func TestSelf(t *testing.T) {
+	result, err := Parse([]byte(planJson))
+	require.NoError(t, err)
+	root, scan := result.Plan, result.Plan.Plans[0]
+	cases := []struct {
+		name     string
+		node     Plan
+		analyze  bool
+		expected float64
+	}{
+		{"subtracts child cost", root, false, 10},
+		{"subtracts child time of all loops", root, true, 3.5},
+		{"multiplies time by loops", scan, true, 16},
+		{"clamps negative values", Plan{TotalCost: 1, Plans: []Plan{{TotalCost: 2}}}, false, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, self(c.node, c.analyze))
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	result, err := Parse([]byte(planJson))
+	require.NoError(t, err)
+	root, scan, index := result.Plan, result.Plan.Plans[0], result.Plan.Plans[1]
+	cases := []struct {
+		name     string
+		node     Plan
+		total    float64
+		analyze  bool
+		expected []string
+	}{
+		{"flags sequential scan with filter", scan, 100, false, []string{
+			"Sequential scan on users, consider adding an index",
+			"Expensive node takes up 80% of total cost",
+		}},
+		{"flags expensive node by time", scan, 20, true, []string{
+			"Sequential scan on users",
+			"Expensive node takes up 80% of total time",
+		}},
+		{"flags mis-estimate with analyze", root, 20, true, []string{
+			"Mis-estimate of 5 rows is off by 100x",
+		}},
+		{"ignores mis-estimate without analyze", root, 100, false, nil},
+		{"ignores cheap index scan", index, 20, true, nil},
+		{"ignores zero total", index, 0, false, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result := warnings(c.node, c.total, c.analyze)
+			assert.Len(t, result, len(c.expected))
+			for i, w := range c.expected {
+				if i < len(result) {
+					assert.Contains(t, result[i], w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package growth
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/inspect"
+	"github.com/supabase/cli/internal/inspect/snapshot"
+	"github.com/supabase/cli/internal/utils"
+)
+
+type Result struct {
+	Table          string       `json:"table" title:"Table"`
+	Size           inspect.Size `json:"size" title:"Size"`
+	Size_per_day   inspect.Size `json:"size_per_day" title:"Size per day"`
+	Rows           int64        `json:"rows" title:"Rows"`
+	Rows_per_day   float64      `json:"rows_per_day" title:"Rows per day" format:"%.0f"`
+	Bloat          float64      `json:"bloat" title:"Bloat" format:"%.1f"`
+	Reaches_target string       `json:"reaches_target" title:"Reaches target"`
+}
+
+// Run does not connect to the database, but projects growth from previously taken snapshots.
+func Run(ctx context.Context, output string, target int64, projectRef string, config pgconn.Config, fsys afero.Fs) error {
+	history, err := snapshot.Load(snapshot.DatabaseKey(projectRef, config), fsys)
+	if err != nil {
+		return err
+	}
+	if len(history) < 2 {
+		return errors.Errorf("At least 2 snapshots are required to estimate growth, found %d. Run %s periodically to record history.", len(history), utils.Aqua("supabase inspect db snapshot"))
+	}
+	result := Estimate(history, target)
+	fmt.Fprintf(os.Stderr, "Estimated from %d snapshots taken between %s and %s\n", len(history), history[0].TakenAt.Format(time.DateOnly), history[len(history)-1].TakenAt.Format(time.DateOnly))
+	return inspect.Render(output, result)
+}
+
+type point struct {
+	days float64
+	size float64
+	rows float64
+}
+
+// Estimate fits a linear trend to the total size and row count of each table in the latest
+// snapshot, ordered by the fastest growing tables first.
+func Estimate(history []snapshot.Snapshot, target int64) []Result {
+	start := history[0].TakenAt
+	points := map[string][]point{}
+	for _, s := range history {
+		days := s.TakenAt.Sub(start).Hours() / 24
+		for _, t := range s.Tables {
+			key := t.Schema + "." + t.Name
+			points[key] = append(points[key], point{
+				days: days,
+				size: float64(t.TotalSize()),
+				rows: float64(t.Row_count),
+			})
+		}
+	}
+	latest := history[len(history)-1]
+	result := []Result{}
+	for _, t := range latest.Tables {
+		key := t.Schema + "." + t.Name
+		sizeRate := slope(points[key], func(p point) float64 { return p.size })
+		r := Result{
+			Table:          key,
+			Size:           inspect.Size(t.TotalSize()),
+			Size_per_day:   inspect.Size(math.Round(sizeRate)),
+			Rows:           t.Row_count,
+			Rows_per_day:   slope(points[key], func(p point) float64 { return p.rows }),
+			Bloat:          t.Bloat,
+			Reaches_target: project(t.TotalSize(), sizeRate, target, latest.TakenAt),
+		}
+		result = append(result, r)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Size_per_day > result[j].Size_per_day
+	})
+	return result
+}
+
+// Least squares slope of values per day, which is 0 for tables with fewer than 2 snapshots.
+func slope(points []point, value func(point) float64) float64 {
+	var n, sumX, sumY float64
+	for _, p := range points {
+		n++
+		sumX += p.days
+		sumY += value(p)
+	}
+	if n < 2 {
+		return 0
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var cov, variance float64
+	for _, p := range points {
+		cov += (p.days - meanX) * (value(p) - meanY)
+		variance += (p.days - meanX) * (p.days - meanX)
+	}
+	if variance == 0 {
+		return 0
+	}
+	return cov / variance
+}
+
+// Returns the date a table of size is projected to reach target, given its growth rate per day.
+func project(size int64, rate float64, target int64, from time.Time) string {
+	if size >= target {
+		return "Reached"
+	}
+	if rate <= 0 {
+		return "Never"
+	}
+	days := float64(target-size) / rate
+	// Avoid overflowing time.Duration for slow growing tables
+	if days > 365*100 {
+		return "Never"
+	}
+	return from.Add(time.Duration(days * 24 * float64(time.Hour))).Format(time.DateOnly)
+}
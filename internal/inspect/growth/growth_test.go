@@ -0,0 +1,92 @@
+package growth
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supabase/cli/internal/inspect"
+	"github.com/supabase/cli/internal/inspect/snapshot"
+)
+
+func TestSlope(t *testing.T) {
+	size := func(p point) float64 { return p.size }
+	cases := []struct {
+		name     string
+		points   []point
+		expected float64
+	}{
+		{"single point", []point{{days: 0, size: 100}}, 0},
+		{"same day", []point{{days: 1, size: 100}, {days: 1, size: 200}}, 0},
+		{"linear growth", []point{{days: 0, size: 100}, {days: 1, size: 150}, {days: 2, size: 200}}, 50},
+		{"least squares fit", []point{{days: 0, size: 0}, {days: 1, size: 10}, {days: 2, size: 8}, {days: 3, size: 30}}, 8.8},
+		{"shrinking table", []point{{days: 0, size: 200}, {days: 2, size: 100}}, -50},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.InDelta(t, c.expected, slope(c.points, size), 1e-9)
+		})
+	}
+}
+
+func TestProject(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		size     int64
+		rate     float64
+		expected string
+	}{
+		{"reached target", 1000, 10, "Reached"},
+		{"reached target without growth", 2000, 0, "Reached"},
+		{"not growing", 100, 0, "Never"},
+		{"shrinking", 100, -10, "Never"},
+		{"projects date", 100, 90, "2024-01-11"},
+		{"avoids duration overflow", 0, 1e-9, "Never"},
+		{"avoids overflow with smallest rate", 0, math.SmallestNonzeroFloat64, "Never"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, project(c.size, c.rate, 1000, from))
+		})
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := []snapshot.Snapshot{{
+		TakenAt: start,
+		Tables: []snapshot.Table{
+			{Schema: "public", Name: "users", Table_size: 1000, Index_size: 0, Row_count: 10},
+			{Schema: "public", Name: "posts", Table_size: 100, Index_size: 100, Row_count: 1},
+		},
+	}, {
+		TakenAt: start.Add(48 * time.Hour),
+		Tables: []snapshot.Table{
+			{Schema: "public", Name: "users", Table_size: 1000, Index_size: 0, Row_count: 10, Bloat: 1.5},
+			{Schema: "public", Name: "posts", Table_size: 600, Index_size: 600, Row_count: 21},
+			{Schema: "public", Name: "tags", Table_size: 10, Row_count: 1},
+		},
+	}}
+	result := Estimate(history, 2000)
+	assert.Equal(t, []Result{{
+		Table:          "public.posts",
+		Size:           inspect.Size(1200),
+		Size_per_day:   inspect.Size(500),
+		Rows:           21,
+		Rows_per_day:   10,
+		Reaches_target: "2024-01-04",
+	}, {
+		Table:          "public.users",
+		Size:           inspect.Size(1000),
+		Rows:           10,
+		Bloat:          1.5,
+		Reaches_target: "Never",
+	}, {
+		Table:          "public.tags",
+		Size:           inspect.Size(10),
+		Rows:           1,
+		Reaches_target: "Never",
+	}}, result)
+}
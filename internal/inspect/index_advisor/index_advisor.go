@@ -0,0 +1,315 @@
+package index_advisor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/inspect"
+	"github.com/supabase/cli/internal/inspect/explain"
+	"github.com/supabase/cli/internal/migration/new"
+	"github.com/supabase/cli/internal/utils"
+	"github.com/supabase/cli/internal/utils/pgxv5"
+)
+
+const (
+	// Same statements as inspect db outliers, excluding utility commands that cannot be explained
+	LIST_TOP_QUERIES = `
+SELECT query
+FROM pg_stat_statements
+WHERE userid = (SELECT usesysid FROM pg_user WHERE usename = current_user LIMIT 1)
+	AND query ~* '^\s*(select|with|update|delete)\s'
+ORDER BY total_exec_time DESC
+LIMIT 10
+`
+	ENABLE_HYPOPG = "CREATE EXTENSION IF NOT EXISTS hypopg WITH SCHEMA extensions"
+	// Parameters are planned as unknown values instead of nulls
+	SET_GENERIC_PLAN    = "SET LOCAL plan_cache_mode = force_generic_plan"
+	CREATE_HYPOTHETICAL = "SELECT indexname FROM hypopg_create_index($1)"
+	RESET_HYPOTHETICAL  = "SELECT hypopg_reset()"
+)
+
+type Suggestion struct {
+	Query       string  `json:"query" title:"Query"`
+	Index       string  `json:"index" title:"Index"`
+	Cost_before float64 `json:"cost_before" title:"Cost before" format:"%.2f"`
+	Cost_after  float64 `json:"cost_after" title:"Cost after" format:"%.2f"`
+	Reduction   float64 `json:"reduction" title:"Reduction" format:"%.1f%%"`
+}
+
+// Index is a candidate btree index on the filtered columns of a sequential scan.
+type Index struct {
+	Schema  string
+	Table   string
+	Columns []string
+}
+
+// Postgres truncates identifiers longer than this many bytes
+const maxIdentifierLength = 63
+
+func (i Index) Name() string {
+	name := strings.Join(append([]string{i.Table}, i.Columns...), "_") + "_idx"
+	if len(name) <= maxIdentifierLength {
+		return name
+	}
+	// Truncated names are suffixed with a hash so that similar indexes do not collide,
+	// which would otherwise skip creating the second index due to IF NOT EXISTS.
+	sum := sha256.Sum256([]byte(name))
+	suffix := "_" + hex.EncodeToString(sum[:4])
+	prefix := name[:maxIdentifierLength-len(suffix)]
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix + suffix
+}
+
+func (i Index) SQL(concurrently bool) string {
+	columns := make([]string, len(i.Columns))
+	for j, c := range i.Columns {
+		columns[j] = pgx.Identifier{c}.Sanitize()
+	}
+	keyword := "INDEX"
+	if concurrently {
+		keyword = "INDEX CONCURRENTLY"
+	}
+	return fmt.Sprintf("CREATE %s IF NOT EXISTS %s ON %s USING btree (%s)",
+		keyword,
+		pgx.Identifier{i.Name()}.Sanitize(),
+		pgx.Identifier{i.Schema, i.Table}.Sanitize(),
+		strings.Join(columns, ", "),
+	)
+}
+
+func Run(ctx context.Context, output, migrationName string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	result, err := Advise(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if err := inspect.Render(output, result); err != nil {
+		return err
+	}
+	var statements []string
+	for _, s := range result {
+		if !utils.SliceContains(statements, s.Index) {
+			statements = append(statements, s.Index)
+		}
+	}
+	if output == utils.OutputPretty && len(statements) > 0 {
+		fmt.Println("Run the following statements to create the suggested indexes:")
+		fmt.Println()
+		for _, sql := range statements {
+			fmt.Println(sql + ";")
+		}
+	}
+	if len(migrationName) > 0 && len(statements) > 0 {
+		return saveMigration(migrationName, statements, fsys)
+	}
+	return nil
+}
+
+// Advise evaluates candidate indexes for the top queries using hypothetical indexes, without modifying the database.
+func Advise(ctx context.Context, conn *pgx.Conn) ([]Suggestion, error) {
+	rows, err := conn.Query(ctx, LIST_TOP_QUERIES)
+	if err != nil {
+		return nil, errors.Errorf("failed to query rows: %w", err)
+	}
+	queries, err := pgxv5.CollectStrings(rows)
+	if err != nil {
+		return nil, err
+	}
+	// Extension and settings are reverted by rolling back
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return nil, errors.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(context.Background()); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to rollback transaction:", err)
+		}
+	}()
+	if _, err := tx.Exec(ctx, ENABLE_HYPOPG); err != nil {
+		return nil, errors.Errorf("failed to enable hypopg: %w", err)
+	}
+	if _, err := tx.Exec(ctx, SET_GENERIC_PLAN); err != nil {
+		return nil, errors.Errorf("failed to set plan cache mode: %w", err)
+	}
+	result := []Suggestion{}
+	for i, sql := range queries {
+		// Statements that fail to plan are skipped, without aborting the outer transaction
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, errors.Errorf("failed to create savepoint: %w", err)
+		}
+		suggestions, err := adviseQuery(ctx, savepoint, fmt.Sprintf("cli_index_advisor_%d", i), sql)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Skipping query:", err)
+			if err := savepoint.Rollback(ctx); err != nil {
+				return nil, errors.Errorf("failed to rollback savepoint: %w", err)
+			}
+			continue
+		}
+		if err := savepoint.Commit(ctx); err != nil {
+			return nil, errors.Errorf("failed to release savepoint: %w", err)
+		}
+		result = append(result, suggestions...)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Reduction > result[j].Reduction
+	})
+	return result, nil
+}
+
+var paramPattern = regexp.MustCompile(`\$(\d+)`)
+
+func adviseQuery(ctx context.Context, tx pgx.Tx, name, sql string) ([]Suggestion, error) {
+	if _, err := tx.Exec(ctx, fmt.Sprintf("PREPARE %s AS %s", name, sql)); err != nil {
+		return nil, errors.Errorf("failed to prepare statement: %w", err)
+	}
+	// Normalised queries replace constants with positional parameters
+	params := 0
+	for _, m := range paramPattern.FindAllStringSubmatch(sql, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > params {
+			params = n
+		}
+	}
+	args := strings.TrimSuffix(strings.Repeat("NULL, ", params), ", ")
+	explainSQL := fmt.Sprintf("EXPLAIN (FORMAT JSON, VERBOSE) EXECUTE %s", name)
+	if params > 0 {
+		explainSQL += "(" + args + ")"
+	}
+	before, err := explainPlan(ctx, tx, explainSQL)
+	if err != nil {
+		return nil, err
+	}
+	var result []Suggestion
+	for _, index := range findCandidates(before) {
+		var hypothetical string
+		if err := tx.QueryRow(ctx, CREATE_HYPOTHETICAL, index.SQL(false)).Scan(&hypothetical); err != nil {
+			return nil, errors.Errorf("failed to create hypothetical index: %w", err)
+		}
+		after, err := explainPlan(ctx, tx, explainSQL)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, RESET_HYPOTHETICAL); err != nil {
+			return nil, errors.Errorf("failed to reset hypothetical indexes: %w", err)
+		}
+		if !usesIndex(after, hypothetical) || after.TotalCost >= before.TotalCost {
+			continue
+		}
+		result = append(result, Suggestion{
+			Query:       sql,
+			Index:       index.SQL(true),
+			Cost_before: before.TotalCost,
+			Cost_after:  after.TotalCost,
+			Reduction:   (before.TotalCost - after.TotalCost) / before.TotalCost * 100,
+		})
+	}
+	return result, nil
+}
+
+func explainPlan(ctx context.Context, tx pgx.Tx, sql string) (explain.Plan, error) {
+	var data []byte
+	if err := tx.QueryRow(ctx, sql).Scan(&data); err != nil {
+		return explain.Plan{}, errors.Errorf("failed to explain statement: %w", err)
+	}
+	return explain.ParsePlan(data)
+}
+
+// Matches column references on the left hand side of indexable operators, ie. (t.col = $1) or ((t.col)::text = $1)
+var filterPattern = regexp.MustCompile(`\(+(?:(?:"(?:[^"]|"")+"|\w+)\.)?("(?:[^"]|"")+"|\w+)\)?(?:::[\w ]+?)?\s+(=|<|>|<=|>=|~~)\s`)
+
+// Returns the columns compared by filter, skipping expressions such as lower(col)
+// which cannot use a plain column index.
+func filterColumns(filter string) (columns, operators []string) {
+	for _, m := range filterPattern.FindAllStringSubmatchIndex(filter, -1) {
+		// Parentheses preceded by a function name are part of a call
+		if start := m[0]; start > 0 && isIdentChar(filter[start-1]) {
+			continue
+		}
+		column := filter[m[2]:m[3]]
+		if strings.HasPrefix(column, `"`) {
+			column = strings.ReplaceAll(column[1:len(column)-1], `""`, `"`)
+		}
+		columns = append(columns, column)
+		operators = append(operators, filter[m[4]:m[5]])
+	}
+	return columns, operators
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '"' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func findCandidates(plan explain.Plan) []Index {
+	var result []Index
+	plan.Walk(func(node explain.Plan) {
+		if node.NodeType != "Seq Scan" || len(node.Filter) == 0 || utils.SliceContains(utils.InternalSchemas, node.Schema) {
+			return
+		}
+		index := Index{Schema: node.Schema, Table: node.Relation}
+		var ranges []string
+		columns, operators := filterColumns(node.Filter)
+		for j, column := range columns {
+			if utils.SliceContains(index.Columns, column) || utils.SliceContains(ranges, column) {
+				continue
+			}
+			// Equality columns come first so that range conditions can use the remaining columns
+			if operators[j] == "=" {
+				index.Columns = append(index.Columns, column)
+			} else {
+				ranges = append(ranges, column)
+			}
+		}
+		index.Columns = append(index.Columns, ranges...)
+		if len(index.Columns) > 0 {
+			result = append(result, index)
+		}
+	})
+	return result
+}
+
+func usesIndex(plan explain.Plan, name string) bool {
+	found := false
+	plan.Walk(func(node explain.Plan) {
+		found = found || node.IndexName == name
+	})
+	return found
+}
+
+func saveMigration(name string, statements []string, fsys afero.Fs) error {
+	var buf bytes.Buffer
+	// Migrations are applied in a transaction, which does not support concurrent index builds
+	buf.WriteString("-- Indexes suggested by supabase inspect db index-advisor\n")
+	for _, sql := range statements {
+		sql = strings.Replace(sql, "INDEX CONCURRENTLY", "INDEX", 1)
+		buf.WriteString(sql + ";\n")
+	}
+	path := new.GetMigrationPath(utils.GetCurrentTimestamp(), name)
+	if err := utils.MkdirIfNotExistFS(fsys, filepath.Dir(path)); err != nil {
+		return err
+	}
+	if err := afero.WriteFile(fsys, path, buf.Bytes(), 0644); err != nil {
+		return errors.Errorf("failed to write migration: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "Created new migration at "+utils.Bold(path))
+	return nil
+}
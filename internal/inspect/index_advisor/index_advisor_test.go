@@ -0,0 +1,135 @@
+package index_advisor
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/inspect/explain"
+	"github.com/supabase/cli/internal/utils"
+)
+
+func TestIndexName(t *testing.T) {
+	t.Run("joins table and columns", func(t *testing.T) {
+		index := Index{Schema: "public", Table: "users", Columns: []string{"org_id", "email"}}
+		assert.Equal(t, "users_org_id_email_idx", index.Name())
+	})
+
+	t.Run("suffixes truncated names with hash", func(t *testing.T) {
+		prefix := strings.Repeat("c", 60)
+		a := Index{Schema: "public", Table: "events", Columns: []string{prefix + "_a"}}
+		b := Index{Schema: "public", Table: "events", Columns: []string{prefix + "_b"}}
+		// Check result
+		assert.Len(t, a.Name(), maxIdentifierLength)
+		assert.Len(t, b.Name(), maxIdentifierLength)
+		assert.NotEqual(t, a.Name(), b.Name())
+		assert.True(t, strings.HasPrefix(a.Name(), "events_ccc"))
+	})
+
+	t.Run("truncates on rune boundary", func(t *testing.T) {
+		index := Index{Schema: "public", Table: "événements", Columns: []string{strings.Repeat("é", 40)}}
+		// Check result
+		assert.LessOrEqual(t, len(index.Name()), maxIdentifierLength)
+		assert.True(t, utf8.ValidString(index.Name()))
+	})
+}
+
+func TestFilterColumns(t *testing.T) {
+	cases := []struct {
+		filter    string
+		columns   []string
+		operators []string
+	}{
+		{"(users.email = $1)", []string{"email"}, []string{"="}},
+		{"((users.email)::text = $1)", []string{"email"}, []string{"="}},
+		{"((orders.created_at)::timestamp with time zone > now())", []string{"created_at"}, []string{">"}},
+		{`("MyTable"."Org Id" = $1)`, []string{"Org Id"}, []string{"="}},
+		{`("quo""ted" = $1)`, []string{`quo"ted`}, []string{"="}},
+		{"((posts.author_id = $1) AND (posts.published_at <= $2))", []string{"author_id", "published_at"}, []string{"=", "<="}},
+		{"(lower(users.email) = $1)", nil, nil},
+		{"(lower((users.email)::text) = $1)", nil, nil},
+		{"((users.name)::text ~~ 'a%'::text)", []string{"name"}, []string{"~~"}},
+	}
+	for _, c := range cases {
+		t.Run(c.filter, func(t *testing.T) {
+			columns, operators := filterColumns(c.filter)
+			assert.Equal(t, c.columns, columns)
+			assert.Equal(t, c.operators, operators)
+		})
+	}
+}
+
+func TestFindCandidates(t *testing.T) {
+	t.Run("orders equality before range columns", func(t *testing.T) {
+		plan := explain.Plan{NodeType: "Limit", Plans: []explain.Plan{{
+			NodeType: "Seq Scan",
+			Schema:   "public",
+			Relation: "posts",
+			Filter:   "((posts.published_at > $2) AND (posts.author_id = $1) AND (posts.author_id = $3))",
+		}}}
+		// Run test
+		result := findCandidates(plan)
+		// Check result
+		assert.Equal(t, []Index{{Schema: "public", Table: "posts", Columns: []string{"author_id", "published_at"}}}, result)
+	})
+
+	t.Run("skips internal schemas and expressions", func(t *testing.T) {
+		plan := explain.Plan{NodeType: "Nested Loop", Plans: []explain.Plan{{
+			NodeType: "Seq Scan",
+			Schema:   "auth",
+			Relation: "users",
+			Filter:   "((users.email)::text = $1)",
+		}, {
+			NodeType: "Seq Scan",
+			Schema:   "public",
+			Relation: "profiles",
+			Filter:   "(lower(profiles.username) = $1)",
+		}, {
+			NodeType:  "Index Scan",
+			Schema:    "public",
+			Relation:  "orgs",
+			IndexName: "orgs_pkey",
+			Filter:    "(orgs.id = $1)",
+		}}}
+		// Run test
+		result := findCandidates(plan)
+		// Check result
+		assert.Empty(t, result)
+	})
+}
+
+func TestSaveMigration(t *testing.T) {
+	t.Run("writes indexes without concurrently", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		statements := []string{
+			Index{Schema: "public", Table: "posts", Columns: []string{"author_id"}}.SQL(true),
+		}
+		// Run test
+		err := saveMigration("add_indexes", statements, fsys)
+		// Check error
+		assert.NoError(t, err)
+		files, err := afero.ReadDir(fsys, utils.MigrationsDir)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		assert.True(t, strings.HasSuffix(files[0].Name(), "_add_indexes.sql"))
+		contents, err := afero.ReadFile(fsys, filepath.Join(utils.MigrationsDir, files[0].Name()))
+		assert.NoError(t, err)
+		assert.Equal(t, `-- Indexes suggested by supabase inspect db index-advisor
+CREATE INDEX IF NOT EXISTS "posts_author_id_idx" ON "public"."posts" USING btree ("author_id");
+`, string(contents))
+	})
+
+	t.Run("throws error on permission denied", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewReadOnlyFs(afero.NewMemMapFs())
+		// Run test
+		err := saveMigration("add_indexes", []string{"CREATE INDEX a ON b (c)"}, fsys)
+		// Check error
+		assert.ErrorContains(t, err, "operation not permitted")
+	})
+}
@@ -2,13 +2,12 @@ package index_sizes
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/go-errors/errors"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/spf13/afero"
-	"github.com/supabase/cli/internal/migration/list"
+	"github.com/supabase/cli/internal/inspect"
 	"github.com/supabase/cli/internal/utils"
 	"github.com/supabase/cli/internal/utils/pgxv5"
 )
@@ -25,27 +24,26 @@ GROUP BY c.relname
 ORDER BY sum(c.relpages) DESC;`
 
 type Result struct {
-	Name string
-	Size string
+	Name string `json:"name" title:"Name"`
+	Size string `json:"size" title:"size"`
 }
 
-func Run(ctx context.Context, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+func Run(ctx context.Context, output string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
 	conn, err := utils.ConnectByConfig(ctx, config, options...)
 	if err != nil {
 		return err
 	}
-	rows, err := conn.Query(ctx, QUERY)
-	if err != nil {
-		return errors.Errorf("failed to query rows: %w", err)
-	}
-	result, err := pgxv5.CollectRows[Result](rows)
+	result, err := Query(ctx, conn)
 	if err != nil {
 		return err
 	}
+	return inspect.Render(output, result)
+}
 
-	table := "|Name|size|\n|-|-|\n"
-	for _, r := range result {
-		table += fmt.Sprintf("|`%s`|`%s`|\n", r.Name, r.Size)
+func Query(ctx context.Context, conn *pgx.Conn) ([]Result, error) {
+	rows, err := conn.Query(ctx, QUERY)
+	if err != nil {
+		return nil, errors.Errorf("failed to query rows: %w", err)
 	}
-	return list.RenderTable(table)
+	return pgxv5.CollectRows[Result](rows)
 }
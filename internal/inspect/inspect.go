@@ -0,0 +1,300 @@
+package inspect
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/supabase/cli/internal/migration/list"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const OutputCsv = "csv"
+
+var (
+	AllowedOutputs = []string{
+		utils.OutputPretty,
+		utils.OutputJson,
+		OutputCsv,
+		utils.OutputYaml,
+	}
+
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// Render writes the result rows of an inspect query to stdout in the given format.
+//
+// Columns are keyed by the json struct tag of each field, and titled by the
+// title struct tag in pretty output. Fields titled "-" are omitted from tables,
+// while the format struct tag overrides how cells are printed in tables. String
+// slices are joined by commas in tables and csv.
+func Render[T any](format string, rows []T) error {
+	switch format {
+	case OutputCsv:
+		return EncodeCsv(os.Stdout, rows)
+	case utils.OutputJson, utils.OutputYaml:
+		// Always encode an array for consistency
+		if rows == nil {
+			rows = []T{}
+		}
+		return utils.EncodeOutput(format, os.Stdout, rows)
+	}
+	return list.RenderTable(MarkdownTable(rows))
+}
+
+type column struct {
+	index  int
+	key    string
+	title  string
+	format string
+}
+
+func columnsOf(t reflect.Type) []column {
+	var result []column
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		c := column{index: i, key: strings.ToLower(f.Name), title: f.Name}
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			c.key = strings.Split(tag, ",")[0]
+		}
+		if tag, ok := f.Tag.Lookup("title"); ok {
+			c.title = tag
+		}
+		c.format = f.Tag.Get("format")
+		result = append(result, c)
+	}
+	return result
+}
+
+// Table holds the titles and formatted cells of columns shown in pretty output.
+type Table struct {
+	Headers []string
+	Rows    [][]string
+}
+
+func NewTable[T any](rows []T) Table {
+	var columns []column
+	for _, c := range columnsOf(reflect.TypeOf((*T)(nil)).Elem()) {
+		if c.title != "-" {
+			columns = append(columns, c)
+		}
+	}
+	var table Table
+	for _, c := range columns {
+		table.Headers = append(table.Headers, c.title)
+	}
+	for _, r := range rows {
+		v := reflect.ValueOf(r)
+		cells := make([]string, len(columns))
+		for i, c := range columns {
+			cells[i] = formatCell(v.Field(c.index).Interface(), c.format)
+		}
+		table.Rows = append(table.Rows, cells)
+	}
+	return table
+}
+
+func formatCell(value any, format string) string {
+	if len(format) > 0 {
+		return fmt.Sprintf(format, value)
+	}
+	if f, ok := value.(float64); ok {
+		return fmt.Sprintf("%.6f", f)
+	}
+	if s, ok := value.([]string); ok {
+		return strings.Join(s, ", ")
+	}
+	// Queries may span multiple lines
+	return whitespacePattern.ReplaceAllString(fmt.Sprint(value), " ")
+}
+
+// Markdown formats the table with each cell quoted as inline code.
+func (t Table) Markdown() string {
+	var sb strings.Builder
+	for _, h := range t.Headers {
+		sb.WriteString("|" + h)
+	}
+	sb.WriteString("|\n" + strings.Repeat("|-", len(t.Headers)) + "|\n")
+	for _, r := range t.Rows {
+		for _, cell := range r {
+			if len(cell) > 0 {
+				cell = "`" + strings.ReplaceAll(cell, "|", `\|`) + "`"
+			}
+			sb.WriteString("|" + cell)
+		}
+		sb.WriteString("|\n")
+	}
+	return sb.String()
+}
+
+func MarkdownTable[T any](rows []T) string {
+	return NewTable(rows).Markdown()
+}
+
+var sizeUnits = map[string]int64{
+	"bytes": 1,
+	"kB":    1 << 10,
+	"MB":    1 << 20,
+	"GB":    1 << 30,
+	"TB":    1 << 40,
+	"PB":    1 << 50,
+}
+
+var sizePattern = regexp.MustCompile(`^([0-9.]+)\s*(bytes|kB|MB|GB|TB|PB)$`)
+
+// ParseSize converts the output of pg_size_pretty back to an approximate number of bytes.
+func ParseSize(size string) (int64, error) {
+	matches := sizePattern.FindStringSubmatch(strings.TrimSpace(size))
+	if len(matches) != 3 {
+		return 0, errors.Errorf("failed to parse size: %s", size)
+	}
+	n, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, errors.Errorf("failed to parse size: %w", err)
+	}
+	return int64(n * float64(sizeUnits[matches[2]])), nil
+}
+
+// FormatSize is the inverse of ParseSize, rounded to 2 decimal places.
+func FormatSize(size int64) string {
+	for _, unit := range []string{"PB", "TB", "GB", "MB", "kB"} {
+		if scale := sizeUnits[unit]; size >= scale {
+			value := math.Round(float64(size)/float64(scale)*100) / 100
+			return strconv.FormatFloat(value, 'f', -1, 64) + " " + unit
+		}
+	}
+	return strconv.FormatInt(size, 10) + " bytes"
+}
+
+// Size is a number of bytes, configured in the same format as pg_size_pretty, ie. 100MB.
+type Size int64
+
+func (s *Size) UnmarshalText(text []byte) error {
+	size, err := ParseSize(string(text))
+	if err != nil {
+		return err
+	}
+	*s = Size(size)
+	return nil
+}
+
+func (s Size) String() string {
+	return FormatSize(int64(s))
+}
+
+// EncodeCsv writes rows with a header of column keys.
+func EncodeCsv[T any](w io.Writer, rows []T) error {
+	columns := columnsOf(reflect.TypeOf((*T)(nil)).Elem())
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.key
+	}
+	records := make([][]string, len(rows))
+	for j, r := range rows {
+		v := reflect.ValueOf(r)
+		records[j] = make([]string, len(columns))
+		for i, c := range columns {
+			value := v.Field(c.index).Interface()
+			if s, ok := value.([]string); ok {
+				records[j][i] = strings.Join(s, ",")
+			} else {
+				records[j][i] = fmt.Sprint(value)
+			}
+		}
+	}
+	return writeCsv(w, header, records)
+}
+
+func writeCsv(w io.Writer, header []string, records [][]string) error {
+	enc := csv.NewWriter(w)
+	if err := enc.Write(header); err != nil {
+		return errors.Errorf("failed to write csv: %w", err)
+	}
+	for _, r := range records {
+		if err := enc.Write(r); err != nil {
+			return errors.Errorf("failed to write csv: %w", err)
+		}
+	}
+	enc.Flush()
+	if err := enc.Error(); err != nil {
+		return errors.Errorf("failed to write csv: %w", err)
+	}
+	return nil
+}
+
+// Column describes a result column that is only known at run time, ie. of user defined queries.
+type Column struct {
+	Key   string
+	Title string
+}
+
+// RenderRows is like Render for rows of text values returned by a dynamic query. Null values are
+// printed as empty cells, and columns titled "-" are omitted from tables.
+func RenderRows(format string, columns []Column, rows [][]*string) error {
+	switch format {
+	case OutputCsv, utils.OutputJson, utils.OutputYaml:
+		return encodeRows(os.Stdout, format, columns, rows)
+	}
+	return list.RenderTable(newRowsTable(columns, rows).Markdown())
+}
+
+func encodeRows(w io.Writer, format string, columns []Column, rows [][]*string) error {
+	if format == OutputCsv {
+		header := make([]string, len(columns))
+		for i, c := range columns {
+			header[i] = c.Key
+		}
+		records := make([][]string, len(rows))
+		for j, r := range rows {
+			records[j] = make([]string, len(columns))
+			for i, cell := range r {
+				if cell != nil {
+					records[j][i] = *cell
+				}
+			}
+		}
+		return writeCsv(w, header, records)
+	}
+	result := make([]map[string]*string, len(rows))
+	for j, r := range rows {
+		result[j] = make(map[string]*string, len(columns))
+		for i, c := range columns {
+			result[j][c.Key] = r[i]
+		}
+	}
+	return utils.EncodeOutput(format, w, result)
+}
+
+func newRowsTable(columns []Column, rows [][]*string) Table {
+	var table Table
+	for _, c := range columns {
+		if c.Title != "-" {
+			table.Headers = append(table.Headers, c.Title)
+		}
+	}
+	for _, r := range rows {
+		var cells []string
+		for i, c := range columns {
+			if c.Title == "-" {
+				continue
+			}
+			var cell string
+			if r[i] != nil {
+				cell = formatCell(*r[i], "")
+			}
+			cells = append(cells, cell)
+		}
+		table.Rows = append(table.Rows, cells)
+	}
+	return table
+}
@@ -0,0 +1,44 @@
+package inspect
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/supabase/cli/internal/utils"
+)
+
+func TestRenderRows(t *testing.T) {
+	name, hidden := "users", "16384"
+	columns := []Column{
+		{Key: "table_name", Title: "Table"},
+		{Key: "oid", Title: "-"},
+		{Key: "comment", Title: "Comment"},
+	}
+	rows := [][]*string{{&name, &hidden, nil}}
+
+	t.Run("omits hidden columns from table", func(t *testing.T) {
+		table := newRowsTable(columns, rows)
+		assert.Equal(t, []string{"Table", "Comment"}, table.Headers)
+		assert.Equal(t, [][]string{{"users", ""}}, table.Rows)
+		assert.Equal(t, "|Table|Comment|\n|-|-|\n|`users`||\n", table.Markdown())
+	})
+
+	t.Run("encodes null as empty csv cell", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, encodeRows(&out, OutputCsv, columns, rows))
+		assert.Equal(t, "table_name,oid,comment\nusers,16384,\n", out.String())
+	})
+
+	t.Run("encodes null as json null", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, encodeRows(&out, utils.OutputJson, columns, rows))
+		assert.JSONEq(t, `[{"table_name": "users", "oid": "16384", "comment": null}]`, out.String())
+	})
+
+	t.Run("encodes empty array without rows", func(t *testing.T) {
+		var out bytes.Buffer
+		assert.NoError(t, encodeRows(&out, utils.OutputJson, columns, nil))
+		assert.JSONEq(t, `[]`, out.String())
+	})
+}
@@ -2,14 +2,12 @@ package locks
 
 import (
 	"context"
-	"fmt"
-	"regexp"
 
 	"github.com/go-errors/errors"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/spf13/afero"
-	"github.com/supabase/cli/internal/migration/list"
+	"github.com/supabase/cli/internal/inspect"
 	"github.com/supabase/cli/internal/utils"
 	"github.com/supabase/cli/internal/utils/pgxv5"
 )
@@ -30,38 +28,30 @@ ORDER BY query_start;
 `
 
 type Result struct {
-	Pid           string
-	Relname       string
-	Transactionid string
-	Granted       string
-	Query         string
-	Age           string
+	Pid           string `json:"pid" title:"pid"`
+	Relname       string `json:"relname" title:"relname"`
+	Transactionid string `json:"transactionid" title:"transaction id"`
+	Granted       string `json:"granted" title:"granted"`
+	Query         string `json:"query" title:"query"`
+	Age           string `json:"age" title:"age"`
 }
 
-func Run(ctx context.Context, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+func Run(ctx context.Context, output string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
 	conn, err := utils.ConnectByConfig(ctx, config, options...)
 	if err != nil {
 		return err
 	}
-	rows, err := conn.Query(ctx, QUERY)
-	if err != nil {
-		return errors.Errorf("failed to query rows: %w", err)
-	}
-	result, err := pgxv5.CollectRows[Result](rows)
+	result, err := Query(ctx, conn)
 	if err != nil {
 		return err
 	}
+	return inspect.Render(output, result)
+}
 
-	table := "|pid|relname|transaction id|granted|query|age|\n|-|-|-|-|-|-|\n"
-	for _, r := range result {
-		// remove whitespace from query
-		re := regexp.MustCompile(`\s+|\r+|\n+|\t+|\v`)
-		query := re.ReplaceAllString(r.Query, " ")
-
-		// escape pipes in query
-		re = regexp.MustCompile(`\|`)
-		query = re.ReplaceAllString(query, `\|`)
-		table += fmt.Sprintf("|`%v`|`%v`|`%v`|`%v`|%s|`%v`|\n", r.Pid, r.Relname, r.Transactionid, r.Granted, query, r.Age)
+func Query(ctx context.Context, conn *pgx.Conn) ([]Result, error) {
+	rows, err := conn.Query(ctx, QUERY)
+	if err != nil {
+		return nil, errors.Errorf("failed to query rows: %w", err)
 	}
-	return list.RenderTable(table)
+	return pgxv5.CollectRows[Result](rows)
 }
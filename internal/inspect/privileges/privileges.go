@@ -0,0 +1,216 @@
+package privileges
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/inspect"
+	"github.com/supabase/cli/internal/utils"
+	"github.com/supabase/cli/internal/utils/pgxv5"
+)
+
+// Lists one row per object and API role, skipping roles that do not exist and objects owned by extensions.
+// Views bypass RLS of their tables unless created with security_invoker, while materialized views and
+// foreign tables do not support RLS at all. Privileges granted only on some columns are suffixed as such.
+const QUERY = `
+SELECT n.nspname AS schema,
+  c.relname AS name,
+  CASE c.relkind WHEN 'v' THEN 'view' WHEN 'm' THEN 'materialized view' WHEN 'f' THEN 'foreign table' ELSE 'table' END AS type,
+  CASE WHEN c.relkind = 'v' AND EXISTS (
+      SELECT 1 FROM unnest(c.reloptions) AS o
+      WHERE lower(o) IN ('security_invoker=true', 'security_invoker=on', 'security_invoker=yes', 'security_invoker=1')
+    ) THEN 'security_invoker'
+    WHEN c.relkind = 'v' THEN 'bypassed'
+    WHEN c.relkind NOT IN ('r', 'p') THEN 'disabled'
+    WHEN c.relforcerowsecurity THEN 'forced'
+    WHEN c.relrowsecurity THEN 'enabled'
+    ELSE 'disabled' END AS rls,
+  r.rolname AS role,
+  array(
+    SELECT CASE WHEN has_table_privilege(r.oid, c.oid, p) THEN p ELSE p || ' (columns)' END
+    FROM unnest(ARRAY['SELECT', 'INSERT', 'UPDATE', 'DELETE']) WITH ORDINALITY AS t(p, i)
+    WHERE has_table_privilege(r.oid, c.oid, p) OR (p <> 'DELETE' AND has_any_column_privilege(r.oid, c.oid, p))
+    ORDER BY i
+  ) AS privileges
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+CROSS JOIN pg_roles r
+WHERE n.nspname = ANY($1::text[])
+AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+AND r.rolname = ANY($2::text[])
+AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.classid = 'pg_class'::regclass AND d.objid = c.oid AND d.deptype = 'e')
+UNION ALL
+SELECT n.nspname AS schema,
+  p.proname || '(' || pg_get_function_identity_arguments(p.oid) || ')' AS name,
+  'function' AS type,
+  '' AS rls,
+  r.rolname AS role,
+  CASE WHEN has_function_privilege(r.oid, p.oid, 'EXECUTE') THEN ARRAY['EXECUTE'] ELSE ARRAY[]::text[] END AS privileges
+FROM pg_proc p
+JOIN pg_namespace n ON n.oid = p.pronamespace
+CROSS JOIN pg_roles r
+WHERE n.nspname = ANY($1::text[])
+AND p.prokind = 'f'
+AND r.rolname = ANY($2::text[])
+AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.classid = 'pg_proc'::regclass AND d.objid = p.oid AND d.deptype = 'e')
+ORDER BY schema, name, role`
+
+const LIST_POLICIES = `
+SELECT schemaname AS schema,
+  tablename AS name,
+  policyname AS policy,
+  permissive,
+  roles::text[] AS roles,
+  cmd AS command
+FROM pg_policies
+WHERE schemaname = ANY($1::text[])
+ORDER BY schemaname, tablename, policyname`
+
+var Roles = []string{"anon", "authenticated", "service_role"}
+
+type Grant struct {
+	Schema     string
+	Name       string
+	Type       string
+	Rls        string
+	Role       string
+	Privileges []string
+}
+
+type Policy struct {
+	Schema     string
+	Name       string
+	Policy     string
+	Permissive string
+	Roles      []string
+	Command    string
+}
+
+func (p Policy) String() string {
+	result := fmt.Sprintf("%s (%s to %s)", p.Policy, p.Command, strings.Join(p.Roles, ", "))
+	if p.Permissive != "PERMISSIVE" {
+		result += " restrictive"
+	}
+	return result
+}
+
+type Result struct {
+	Schema        string   `json:"schema" title:"Schema"`
+	Name          string   `json:"name" title:"Name"`
+	Type          string   `json:"type" title:"Type"`
+	Rls           string   `json:"rls" title:"RLS"`
+	Anon          []string `json:"anon" title:"anon"`
+	Authenticated []string `json:"authenticated" title:"authenticated"`
+	Service_role  []string `json:"service_role" title:"service_role"`
+	Policies      []string `json:"policies" title:"Policies"`
+}
+
+func Run(ctx context.Context, output string, schema []string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	if len(schema) == 0 {
+		schema = exposedSchemas()
+	}
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	result, err := Query(ctx, schema, conn)
+	if err != nil {
+		return err
+	}
+	if err := inspect.Render(output, result); err != nil {
+		return err
+	}
+	if output == utils.OutputPretty {
+		if exposed := countExposed(result); exposed > 0 {
+			fmt.Fprintf(os.Stderr, "%s %d tables or views without RLS can be accessed by anon or authenticated roles.\n", utils.Yellow("WARNING:"), exposed)
+		}
+	}
+	return nil
+}
+
+// Schemas served by the Data API are only known when config is loaded, ie. local or linked.
+func exposedSchemas() []string {
+	if len(utils.Config.Api.Schemas) > 0 {
+		return utils.Config.Api.Schemas
+	}
+	return []string{"public"}
+}
+
+func Query(ctx context.Context, schema []string, conn *pgx.Conn) ([]Result, error) {
+	rows, err := conn.Query(ctx, QUERY, schema, Roles)
+	if err != nil {
+		return nil, errors.Errorf("failed to query rows: %w", err)
+	}
+	grants, err := pgxv5.CollectRows[Grant](rows)
+	if err != nil {
+		return nil, err
+	}
+	if rows, err = conn.Query(ctx, LIST_POLICIES, schema); err != nil {
+		return nil, errors.Errorf("failed to query rows: %w", err)
+	}
+	policies, err := pgxv5.CollectRows[Policy](rows)
+	if err != nil {
+		return nil, err
+	}
+	return pivot(grants, policies), nil
+}
+
+// Combines grants of each role into a single row per object, in the order they are listed.
+func pivot(grants []Grant, policies []Policy) []Result {
+	applied := map[string][]string{}
+	for _, p := range policies {
+		key := p.Schema + "." + p.Name
+		applied[key] = append(applied[key], p.String())
+	}
+	result := []Result{}
+	index := map[string]int{}
+	for _, g := range grants {
+		key := g.Schema + "." + g.Name
+		i, ok := index[key]
+		if !ok {
+			i = len(result)
+			index[key] = i
+			r := Result{
+				Schema:        g.Schema,
+				Name:          g.Name,
+				Type:          g.Type,
+				Rls:           g.Rls,
+				Anon:          []string{},
+				Authenticated: []string{},
+				Service_role:  []string{},
+				Policies:      []string{},
+			}
+			if g.Type == "table" {
+				r.Policies = append(r.Policies, applied[key]...)
+			}
+			result = append(result, r)
+		}
+		switch g.Role {
+		case "anon":
+			result[i].Anon = g.Privileges
+		case "authenticated":
+			result[i].Authenticated = g.Privileges
+		case "service_role":
+			result[i].Service_role = g.Privileges
+		}
+	}
+	return result
+}
+
+// Tables without RLS, and views that bypass it, are readable through the Data API by any client with the anon key.
+func countExposed(result []Result) int {
+	var count int
+	for _, r := range result {
+		if (r.Rls == "disabled" || r.Rls == "bypassed") && len(r.Anon)+len(r.Authenticated) > 0 {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,63 @@
+package privileges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPivot(t *testing.T) {
+	grants := []Grant{
+		{Schema: "public", Name: "posts", Type: "table", Rls: "enabled", Role: "anon", Privileges: []string{"SELECT"}},
+		{Schema: "public", Name: "posts", Type: "table", Rls: "enabled", Role: "authenticated", Privileges: []string{"SELECT", "INSERT"}},
+		{Schema: "public", Name: "posts", Type: "table", Rls: "enabled", Role: "service_role", Privileges: []string{"SELECT", "INSERT", "UPDATE", "DELETE"}},
+		{Schema: "public", Name: "profiles", Type: "view", Rls: "bypassed", Role: "anon", Privileges: []string{"SELECT (columns)"}},
+		{Schema: "public", Name: "search(text)", Type: "function", Role: "authenticated", Privileges: []string{"EXECUTE"}},
+	}
+	policies := []Policy{
+		{Schema: "public", Name: "posts", Policy: "read all", Permissive: "PERMISSIVE", Roles: []string{"public"}, Command: "SELECT"},
+		{Schema: "public", Name: "posts", Policy: "own rows", Permissive: "RESTRICTIVE", Roles: []string{"authenticated"}, Command: "ALL"},
+		// Policies on views are not applied
+		{Schema: "public", Name: "profiles", Policy: "ignored", Permissive: "PERMISSIVE", Roles: []string{"anon"}, Command: "SELECT"},
+	}
+	assert.Equal(t, []Result{{
+		Schema:        "public",
+		Name:          "posts",
+		Type:          "table",
+		Rls:           "enabled",
+		Anon:          []string{"SELECT"},
+		Authenticated: []string{"SELECT", "INSERT"},
+		Service_role:  []string{"SELECT", "INSERT", "UPDATE", "DELETE"},
+		Policies:      []string{"read all (SELECT to public)", "own rows (ALL to authenticated) restrictive"},
+	}, {
+		Schema:        "public",
+		Name:          "profiles",
+		Type:          "view",
+		Rls:           "bypassed",
+		Anon:          []string{"SELECT (columns)"},
+		Authenticated: []string{},
+		Service_role:  []string{},
+		Policies:      []string{},
+	}, {
+		Schema:        "public",
+		Name:          "search(text)",
+		Type:          "function",
+		Anon:          []string{},
+		Authenticated: []string{"EXECUTE"},
+		Service_role:  []string{},
+		Policies:      []string{},
+	}}, pivot(grants, policies))
+	assert.Equal(t, []Result{}, pivot(nil, policies))
+}
+
+func TestCountExposed(t *testing.T) {
+	result := []Result{
+		{Name: "disabled table", Rls: "disabled", Anon: []string{"SELECT"}},
+		{Name: "definer view", Rls: "bypassed", Authenticated: []string{"SELECT (columns)"}},
+		{Name: "invoker view", Rls: "security_invoker", Anon: []string{"SELECT"}},
+		{Name: "enabled table", Rls: "enabled", Anon: []string{"SELECT"}},
+		{Name: "private table", Rls: "disabled", Service_role: []string{"SELECT"}},
+		{Name: "function", Anon: []string{"EXECUTE"}},
+	}
+	assert.Equal(t, 2, countExposed(result))
+}
@@ -0,0 +1,253 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/inspect"
+	"github.com/supabase/cli/internal/inspect/bloat"
+	"github.com/supabase/cli/internal/inspect/blocking"
+	"github.com/supabase/cli/internal/inspect/cache"
+	"github.com/supabase/cli/internal/inspect/calls"
+	"github.com/supabase/cli/internal/inspect/check"
+	"github.com/supabase/cli/internal/inspect/index_sizes"
+	"github.com/supabase/cli/internal/inspect/index_usage"
+	"github.com/supabase/cli/internal/inspect/locks"
+	"github.com/supabase/cli/internal/inspect/long_running_queries"
+	"github.com/supabase/cli/internal/inspect/outliers"
+	"github.com/supabase/cli/internal/inspect/replication_slots"
+	"github.com/supabase/cli/internal/inspect/role_connections"
+	"github.com/supabase/cli/internal/inspect/seq_scans"
+	"github.com/supabase/cli/internal/inspect/table_index_sizes"
+	"github.com/supabase/cli/internal/inspect/table_record_counts"
+	"github.com/supabase/cli/internal/inspect/table_sizes"
+	"github.com/supabase/cli/internal/inspect/total_index_size"
+	"github.com/supabase/cli/internal/inspect/total_table_sizes"
+	"github.com/supabase/cli/internal/inspect/unused_indexes"
+	"github.com/supabase/cli/internal/inspect/vacuum_stats"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatHtml     = "html"
+	FormatJson     = utils.OutputJson
+
+	// Inspections are spread across a small number of connections to limit load on the database
+	MaxConnections = 4
+	// Slow inspections are cancelled so that they do not hold up the rest of the report
+	SET_STATEMENT_TIMEOUT = "SET statement_timeout = '30s'"
+)
+
+var (
+	AllowedFormats = []string{FormatMarkdown, FormatHtml, FormatJson}
+
+	//go:embed templates/report.md
+	markdownTemplate string
+	//go:embed templates/report.html
+	htmlTemplate string
+
+	markdownReport = template.Must(template.New("report").Parse(markdownTemplate))
+	htmlReport     = htmltemplate.Must(htmltemplate.New("report").Parse(htmlTemplate))
+)
+
+type Finding struct {
+	Section string `json:"section"`
+	Message string `json:"message"`
+}
+
+type Section struct {
+	Name        string        `json:"name"`
+	Title       string        `json:"title"`
+	CollectedAt time.Time     `json:"collected_at"`
+	Rows        any           `json:"rows"`
+	Error       string        `json:"error,omitempty"`
+	Table       inspect.Table `json:"-"`
+}
+
+type Report struct {
+	Host       string    `json:"host"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Findings   []Finding `json:"findings"`
+	Sections   []Section `json:"sections"`
+}
+
+type inspection struct {
+	name  string
+	title string
+	run   func(context.Context, *pgx.Conn) (Section, []Finding)
+}
+
+func newInspection[T any](name, title string, query func(context.Context, *pgx.Conn) ([]T, error), check func(T) []check.Violation) inspection {
+	return inspection{name: name, title: title, run: func(ctx context.Context, conn *pgx.Conn) (Section, []Finding) {
+		section := Section{Name: name, Title: title}
+		rows, err := query(ctx, conn)
+		section.CollectedAt = time.Now().UTC()
+		if err != nil {
+			section.Error = err.Error()
+			return section, nil
+		}
+		section.Rows = rows
+		section.Table = inspect.NewTable(rows)
+		var findings []Finding
+		for i := 0; check != nil && i < len(rows); i++ {
+			for _, v := range check(rows[i]) {
+				findings = append(findings, Finding{Section: title, Message: v.String()})
+			}
+		}
+		return section, findings
+	}}
+}
+
+var rules = check.DefaultRules()
+
+// Inspections are listed in the same order as inspect db subcommands.
+var inspections = []inspection{
+	newInspection("cache-hit", "Cache hit rates", cache.Query, rules.CacheHit),
+	newInspection("replication-slots", "Replication slots", replication_slots.Query, rules.ReplicationLag),
+	newInspection("index-usage", "Index usage", index_usage.Query, nil),
+	newInspection("locks", "Locks", locks.Query, nil),
+	newInspection("blocking", "Blocking queries", blocking.Query, nil),
+	newInspection("outliers", "Queries by total execution time", outliers.Query, nil),
+	newInspection("calls", "Queries by number of calls", calls.Query, nil),
+	newInspection("total-index-size", "Total index size", total_index_size.Query, nil),
+	newInspection("index-sizes", "Index sizes", index_sizes.Query, nil),
+	newInspection("table-sizes", "Table sizes", table_sizes.Query, nil),
+	newInspection("table-index-sizes", "Table index sizes", table_index_sizes.Query, nil),
+	newInspection("total-table-sizes", "Total table sizes", total_table_sizes.Query, nil),
+	newInspection("unused-indexes", "Unused indexes", unused_indexes.Query, rules.UnusedIndex),
+	newInspection("seq-scans", "Sequential scans", seq_scans.Query, nil),
+	newInspection("long-running-queries", "Long running queries", long_running_queries.Query, nil),
+	newInspection("table-record-counts", "Table record counts", table_record_counts.Query, nil),
+	newInspection("bloat", "Bloat", bloat.Query, rules.Bloat),
+	newInspection("vacuum-stats", "Vacuum statistics", vacuum_stats.Query, rules.DeadTuples),
+	newInspection("role-connections", "Role connections", role_connections.Query, nil),
+}
+
+func Run(ctx context.Context, format, outputFile string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	report, err := Collect(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	if len(outputFile) == 0 {
+		return report.Encode(format, os.Stdout)
+	}
+	var buf bytes.Buffer
+	if err := report.Encode(format, &buf); err != nil {
+		return err
+	}
+	if err := utils.MkdirIfNotExistFS(fsys, filepath.Dir(outputFile)); err != nil {
+		return err
+	}
+	if err := afero.WriteFile(fsys, outputFile, buf.Bytes(), 0644); err != nil {
+		return errors.Errorf("failed to write report: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "Saved report to", utils.Bold(outputFile))
+	return nil
+}
+
+// Collect runs all inspections concurrently. Failed inspections are reported
+// in their own section instead of failing the whole report.
+func Collect(ctx context.Context, config pgconn.Config, options ...func(*pgx.ConnConfig)) (Report, error) {
+	report := Report{Host: config.Host, StartedAt: time.Now().UTC()}
+	conns, err := connectPool(ctx, MaxConnections, config, options...)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close(context.Background())
+		}
+	}()
+	if err != nil {
+		return report, err
+	}
+	queue := make(chan int, len(inspections))
+	for i := range inspections {
+		queue <- i
+	}
+	close(queue)
+	sections := make([]Section, len(inspections))
+	findings := make([][]Finding, len(inspections))
+	done := make(chan int, len(inspections))
+	var wg sync.WaitGroup
+	for _, conn := range conns {
+		wg.Add(1)
+		go func(conn *pgx.Conn) {
+			defer wg.Done()
+			for i := range queue {
+				// Each index is written by exactly one worker
+				sections[i], findings[i] = inspections[i].run(ctx, conn)
+				done <- i
+			}
+		}(conn)
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	// Progress is printed from a single goroutine so that lines do not interleave
+	count := 0
+	for i := range done {
+		count++
+		fmt.Fprintf(os.Stderr, "Inspected %s (%d/%d)\n", inspections[i].name, count, len(inspections))
+	}
+	report.Sections = sections
+	report.Findings = []Finding{}
+	for _, f := range findings {
+		report.Findings = append(report.Findings, f...)
+	}
+	report.FinishedAt = time.Now().UTC()
+	return report, nil
+}
+
+func connectPool(ctx context.Context, size int, config pgconn.Config, options ...func(*pgx.ConnConfig)) ([]*pgx.Conn, error) {
+	var conns []*pgx.Conn
+	for i := 0; i < size; i++ {
+		// Only log the first connection attempt
+		w := io.Discard
+		if i == 0 {
+			w = os.Stderr
+		}
+		conn, err := utils.ConnectByConfigStream(ctx, config, w, options...)
+		if err != nil {
+			return conns, err
+		}
+		conns = append(conns, conn)
+		if _, err := conn.Exec(ctx, SET_STATEMENT_TIMEOUT); err != nil {
+			return conns, errors.Errorf("failed to set statement timeout: %w", err)
+		}
+	}
+	return conns, nil
+}
+
+func (r Report) Encode(format string, w io.Writer) error {
+	var err error
+	switch format {
+	case FormatJson:
+		return utils.EncodeOutput(format, w, r)
+	case FormatHtml:
+		err = htmlReport.Execute(w, r)
+	default:
+		err = markdownReport.Execute(w, r)
+	}
+	if err != nil {
+		return errors.Errorf("failed to render report: %w", err)
+	}
+	return nil
+}
+
+func (r Report) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond)
+}
@@ -0,0 +1,108 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/inspect"
+	"github.com/supabase/cli/internal/inspect/cache"
+	"github.com/supabase/cli/internal/inspect/check"
+)
+
+//go:embed testdata/*
+var testdata embed.FS
+
+func TestNewInspection(t *testing.T) {
+	t.Run("aggregates findings from every row", func(t *testing.T) {
+		query := func(context.Context, *pgx.Conn) ([]cache.Result, error) {
+			return []cache.Result{
+				{Name: "index hit rate", Ratio: 0.95},
+				{Name: "table hit rate", Ratio: 0.999},
+				{Name: "other hit rate", Ratio: 0.5},
+			}, nil
+		}
+		inspection := newInspection("cache-hit", "Cache hit rates", query, check.DefaultRules().CacheHit)
+		// Run test
+		section, findings := inspection.run(context.Background(), nil)
+		// Check result
+		assert.Empty(t, section.Error)
+		assert.Equal(t, "cache-hit", section.Name)
+		assert.Len(t, section.Table.Rows, 3)
+		assert.Equal(t, []Finding{
+			{Section: "Cache hit rates", Message: "index hit rate is 95.00%, below 99.00%."},
+			{Section: "Cache hit rates", Message: "other hit rate is 50.00%, below 99.00%."},
+		}, findings)
+	})
+
+	t.Run("skips checks when not configured", func(t *testing.T) {
+		query := func(context.Context, *pgx.Conn) ([]cache.Result, error) {
+			return []cache.Result{{Name: "index hit rate", Ratio: 0.5}}, nil
+		}
+		inspection := newInspection[cache.Result]("cache-hit", "Cache hit rates", query, nil)
+		// Run test
+		section, findings := inspection.run(context.Background(), nil)
+		// Check result
+		assert.Empty(t, section.Error)
+		assert.Empty(t, findings)
+	})
+
+	t.Run("reports query error in section", func(t *testing.T) {
+		query := func(context.Context, *pgx.Conn) ([]cache.Result, error) {
+			return nil, errors.New("canceling statement due to statement timeout")
+		}
+		inspection := newInspection("cache-hit", "Cache hit rates", query, check.DefaultRules().CacheHit)
+		// Run test
+		section, findings := inspection.run(context.Background(), nil)
+		// Check result
+		assert.Equal(t, "canceling statement due to statement timeout", section.Error)
+		assert.Nil(t, section.Rows)
+		assert.Empty(t, findings)
+	})
+}
+
+func TestEncodeReport(t *testing.T) {
+	startedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	rows := []cache.Result{{Name: "index hit rate", Ratio: 0.95}, {Name: "table <hit> rate", Ratio: 1}}
+	report := Report{
+		Host:       "db.example.supabase.co",
+		StartedAt:  startedAt,
+		FinishedAt: startedAt.Add(1500 * time.Millisecond),
+		Findings:   []Finding{{Section: "Cache hit rates", Message: "index hit rate is 95.00%, below 99.00%."}},
+		Sections: []Section{{
+			Name:        "cache-hit",
+			Title:       "Cache hit rates",
+			CollectedAt: startedAt.Add(time.Second),
+			Rows:        rows,
+			Table:       inspect.NewTable(rows),
+		}, {
+			Name:        "outliers",
+			Title:       "Queries by total execution time",
+			CollectedAt: startedAt.Add(time.Second),
+			Error:       `relation "pg_stat_statements" does not exist`,
+		}},
+	}
+
+	for format, golden := range map[string]string{
+		FormatMarkdown: "testdata/report.md",
+		FormatHtml:     "testdata/report.html",
+		FormatJson:     "testdata/report.json",
+	} {
+		t.Run("encodes "+format, func(t *testing.T) {
+			expected, err := testdata.ReadFile(golden)
+			require.NoError(t, err)
+			var out bytes.Buffer
+			// Run test
+			err = report.Encode(format, &out)
+			// Check error
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), out.String())
+		})
+	}
+}
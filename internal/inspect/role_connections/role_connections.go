@@ -8,7 +8,7 @@ import (
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/spf13/afero"
-	"github.com/supabase/cli/internal/migration/list"
+	"github.com/supabase/cli/internal/inspect"
 	"github.com/supabase/cli/internal/utils"
 	"github.com/supabase/cli/internal/utils/pgxv5"
 )
@@ -32,36 +32,32 @@ from
 order by 2 desc`
 
 type Result struct {
-	Rolname            string
-	Active_connections int
-	Connection_limit   int
+	Rolname            string `json:"rolname" title:"Role Name"`
+	Active_connections int    `json:"active_connections" title:"Active connection"`
+	Connection_limit   int    `json:"connection_limit" title:"-"`
 }
 
-func Run(ctx context.Context, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+func Run(ctx context.Context, output string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
 	conn, err := utils.ConnectByConfig(ctx, config, options...)
 	if err != nil {
 		return err
 	}
-	rows, err := conn.Query(ctx, QUERY)
+	result, err := Query(ctx, conn)
 	if err != nil {
-		return errors.Errorf("failed to query rows: %w", err)
+		return err
 	}
-	result, err := pgxv5.CollectRows[Result](rows)
-	if err != nil {
+
+	if err := inspect.Render(output, result); err != nil {
 		return err
 	}
+	if output != utils.OutputPretty {
+		return nil
+	}
 
-	table := "|Role Name|Active connction|\n|-|-|\n"
 	sum := 0
 	for _, r := range result {
-		table += fmt.Sprintf("|`%s`|`%d`|\n", r.Rolname, r.Active_connections)
 		sum += r.Active_connections
 	}
-
-	if err := list.RenderTable(table); err != nil {
-		return err
-	}
-
 	if len(result) > 0 {
 		fmt.Printf("\nActive connections %d/%d\n\n", sum, result[0].Connection_limit)
 	}
@@ -73,3 +69,11 @@ func Run(ctx context.Context, config pgconn.Config, fsys afero.Fs, options ...fu
 
 	return nil
 }
+
+func Query(ctx context.Context, conn *pgx.Conn) ([]Result, error) {
+	rows, err := conn.Query(ctx, QUERY)
+	if err != nil {
+		return nil, errors.Errorf("failed to query rows: %w", err)
+	}
+	return pgxv5.CollectRows[Result](rows)
+}
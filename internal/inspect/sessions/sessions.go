@@ -0,0 +1,209 @@
+package sessions
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/inspect"
+	"github.com/supabase/cli/internal/utils"
+	"github.com/supabase/cli/internal/utils/pgxv5"
+)
+
+const (
+	// Same filters as long_running_queries, with a configurable threshold. Only client sessions
+	// of user roles are listed, which excludes replication senders and platform services.
+	LIST_LONG_RUNNING = `
+SELECT
+  pid,
+  (now() - query_start)::text AS duration,
+  query
+FROM pg_stat_activity
+WHERE query <> ''::text
+  AND state <> 'idle'
+  AND pid <> pg_backend_pid()
+  AND backend_type = 'client backend'
+  AND usename NOT LIKE 'supabase\_%'
+  AND now() - query_start > make_interval(secs => $1)
+ORDER BY now() - query_start DESC`
+	// Same joins as blocking, selecting the client sessions of user roles that hold locks
+	LIST_BLOCKING = `
+SELECT
+  ka.pid,
+  (now() - ka.query_start)::text AS duration,
+  ka.query
+FROM pg_catalog.pg_stat_activity ka
+WHERE ka.pid <> pg_backend_pid()
+  AND ka.backend_type = 'client backend'
+  AND ka.usename NOT LIKE 'supabase\_%'
+  AND now() - ka.query_start > make_interval(secs => $1)
+  AND EXISTS (
+    SELECT 1
+    FROM pg_catalog.pg_locks bl
+    JOIN pg_catalog.pg_locks kl
+      ON bl.transactionid = kl.transactionid AND bl.pid != kl.pid
+    WHERE NOT bl.granted AND kl.pid = ka.pid
+  )
+ORDER BY now() - ka.query_start DESC`
+	CANCEL_BACKEND    = "SELECT pg_cancel_backend($1)"
+	TERMINATE_BACKEND = "SELECT pg_terminate_backend($1)"
+)
+
+// Long running queries are listed from 5 minutes by default, same as inspect db long-running-queries.
+var DefaultLongRunning = 5 * time.Minute
+
+type Session struct {
+	Pid      int32  `json:"pid" title:"pid"`
+	Duration string `json:"duration" title:"Duration"`
+	Query    string `json:"query" title:"Query"`
+}
+
+// Lister returns sessions running queries for longer than the given duration.
+type Lister func(ctx context.Context, conn *pgx.Conn, olderThan time.Duration) ([]Session, error)
+
+func ListLongRunning(ctx context.Context, conn *pgx.Conn, olderThan time.Duration) ([]Session, error) {
+	return listSessions(ctx, conn, LIST_LONG_RUNNING, olderThan)
+}
+
+func ListBlocking(ctx context.Context, conn *pgx.Conn, olderThan time.Duration) ([]Session, error) {
+	return listSessions(ctx, conn, LIST_BLOCKING, olderThan)
+}
+
+func listSessions(ctx context.Context, conn *pgx.Conn, sql string, olderThan time.Duration) ([]Session, error) {
+	rows, err := conn.Query(ctx, sql, olderThan.Seconds())
+	if err != nil {
+		return nil, errors.Errorf("failed to query rows: %w", err)
+	}
+	return pgxv5.CollectRows[Session](rows)
+}
+
+// Signal cancels the current query of a session, or terminates the session entirely.
+func Signal(ctx context.Context, conn *pgx.Conn, pid int32, terminate bool) error {
+	sql := CANCEL_BACKEND
+	if terminate {
+		sql = TERMINATE_BACKEND
+	}
+	var ok bool
+	if err := conn.QueryRow(ctx, sql, pid).Scan(&ok); err != nil {
+		return errors.Errorf("failed to signal backend %d: %w", pid, err)
+	}
+	if !ok {
+		return errors.Errorf("failed to signal backend %d: session not found", pid)
+	}
+	return nil
+}
+
+// Run cancels or terminates all sessions returned by list, printing them first.
+// Unless yes is set, the user is asked to confirm before any session is signalled.
+func Run(ctx context.Context, output string, list Lister, olderThan time.Duration, terminate, dryRun, yes bool, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	result, err := list(ctx, conn, olderThan)
+	if err != nil {
+		return err
+	}
+	if err := inspect.Render(output, result); err != nil {
+		return err
+	}
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "Dry run: %d sessions would be %s.\n", len(result), describe(terminate))
+		return nil
+	}
+	if len(result) == 0 {
+		fmt.Fprintln(os.Stderr, "No sessions found.")
+		return nil
+	}
+	if !yes {
+		action := "cancel the queries of"
+		if terminate {
+			action = "terminate"
+		}
+		msg := fmt.Sprintf("Do you want to %s %d sessions?", action, len(result))
+		if shouldSignal := utils.PromptYesNo(msg, false, os.Stdin); !shouldSignal {
+			return errors.New(context.Canceled)
+		}
+	}
+	var errs []error
+	for _, s := range result {
+		if err := Signal(ctx, conn, s.Pid, terminate); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d sessions %s.\n", len(result)-len(errs), describe(terminate))
+	return errors.Join(errs...)
+}
+
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// RunInteractive prompts the user to select sessions to cancel or terminate, until none are left or the user is done.
+func RunInteractive(ctx context.Context, list Lister, olderThan time.Duration, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	for {
+		result, err := list(ctx, conn, olderThan)
+		if err != nil {
+			return err
+		}
+		if len(result) == 0 {
+			fmt.Fprintln(os.Stderr, "No sessions found.")
+			return nil
+		}
+		items := make([]utils.PromptItem, len(result)+1)
+		for i, s := range result {
+			items[i] = utils.PromptItem{
+				Summary: fmt.Sprintf("pid %d (%s)", s.Pid, s.Duration),
+				Details: truncate(whitespacePattern.ReplaceAllString(s.Query, " "), 80),
+				Index:   i,
+			}
+		}
+		items[len(result)] = utils.PromptItem{Summary: "Done", Index: len(result)}
+		choice, err := utils.PromptChoice(ctx, "Select a session to cancel or terminate:", items)
+		if err != nil {
+			return err
+		}
+		if choice.Index == len(result) {
+			return nil
+		}
+		selected := result[choice.Index]
+		action, err := utils.PromptChoice(ctx, fmt.Sprintf("What would you like to do with pid %d?", selected.Pid), []utils.PromptItem{
+			{Summary: "Cancel query", Details: "pg_cancel_backend", Index: 0},
+			{Summary: "Terminate session", Details: "pg_terminate_backend", Index: 1},
+		})
+		if err != nil {
+			return err
+		}
+		terminate := action.Index == 1
+		if err := Signal(ctx, conn, selected.Pid, terminate); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Session with pid %d %s.\n", selected.Pid, describe(terminate))
+	}
+}
+
+// Truncates by rune so that multi-byte characters are not split.
+func truncate(query string, max int) string {
+	runes := []rune(query)
+	if len(runes) <= max {
+		return query
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+func describe(terminate bool) string {
+	if terminate {
+		return "terminated"
+	}
+	return "cancelled"
+}
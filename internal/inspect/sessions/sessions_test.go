@@ -0,0 +1,67 @@
+package sessions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/supabase/cli/internal/testing/pgtest"
+	"github.com/supabase/cli/internal/utils"
+)
+
+var dbConfig = pgconn.Config{
+	Host:     "127.0.0.1",
+	Port:     5432,
+	User:     "admin",
+	Password: "password",
+	Database: "postgres",
+}
+
+func listFixed(ctx context.Context, conn *pgx.Conn, olderThan time.Duration) ([]Session, error) {
+	return []Session{{Pid: 42, Duration: "00:10:00", Query: "select pg_sleep(600)"}}, nil
+}
+
+func TestRunSessions(t *testing.T) {
+	t.Run("asks for confirmation before cancelling", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		// Run test: stdin is not a terminal, so the prompt defaults to no
+		err := Run(context.Background(), utils.OutputJson, listFixed, time.Minute, false, false, false, dbConfig, afero.NewMemMapFs(), conn.Intercept)
+		// Check error
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("skips confirmation on dry run", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		// Run test
+		err := Run(context.Background(), utils.OutputJson, listFixed, time.Minute, true, true, false, dbConfig, afero.NewMemMapFs(), conn.Intercept)
+		// Check error
+		assert.NoError(t, err)
+	})
+
+	t.Run("skips confirmation without sessions", func(t *testing.T) {
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		listEmpty := func(context.Context, *pgx.Conn, time.Duration) ([]Session, error) {
+			return nil, nil
+		}
+		// Run test
+		err := Run(context.Background(), utils.OutputJson, listEmpty, time.Minute, false, false, false, dbConfig, afero.NewMemMapFs(), conn.Intercept)
+		// Check error
+		assert.NoError(t, err)
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "select 1", truncate("select 1", 10))
+	assert.Equal(t, "select 'é...", truncate("select 'ééééé'", 12))
+	assert.Equal(t, "select '日本...", truncate("select '日本語テキスト'", 13))
+}
@@ -0,0 +1,162 @@
+package snapshot
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/inspect"
+	"github.com/supabase/cli/internal/inspect/bloat"
+	"github.com/supabase/cli/internal/utils"
+	"github.com/supabase/cli/internal/utils/pgxv5"
+)
+
+// Same tables as inspect db table-sizes, with sizes in bytes instead of pretty printed
+const QUERY = `
+SELECT n.nspname AS schema,
+  c.relname AS name,
+  pg_table_size(c.oid) AS table_size,
+  pg_indexes_size(c.oid) AS index_size,
+  coalesce(s.n_live_tup, 0) AS row_count
+FROM pg_class c
+LEFT JOIN pg_namespace n ON (n.oid = c.relnamespace)
+LEFT JOIN pg_stat_user_tables s ON (s.relid = c.oid)
+WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+AND n.nspname !~ '^pg_toast'
+AND c.relkind='r'
+ORDER BY pg_table_size(c.oid) DESC;`
+
+// Table sizes are in bytes, and bloat is the estimated ratio of allocated to used space.
+type Table struct {
+	Schema     string  `json:"schema"`
+	Name       string  `json:"name"`
+	Table_size int64   `json:"table_size"`
+	Index_size int64   `json:"index_size"`
+	Row_count  int64   `json:"row_count"`
+	Bloat      float64 `json:"bloat"`
+}
+
+func (t Table) TotalSize() int64 {
+	return t.Table_size + t.Index_size
+}
+
+// Snapshot is a line in the history file, which is appended to each time a snapshot is taken.
+type Snapshot struct {
+	// Identifies the database so that snapshots of local and linked projects are kept apart
+	Database string    `json:"database"`
+	TakenAt  time.Time `json:"taken_at"`
+	Tables   []Table   `json:"tables"`
+}
+
+// DatabaseKey identifies a linked database by its project ref, which stays the same when connecting
+// through the pooler. Other databases are identified by their connection config, excluding credentials.
+func DatabaseKey(projectRef string, config pgconn.Config) string {
+	if len(projectRef) > 0 {
+		return projectRef
+	}
+	return fmt.Sprintf("%s:%d/%s", config.Host, config.Port, config.Database)
+}
+
+func Run(ctx context.Context, projectRef string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	tables, err := Query(ctx, conn)
+	if err != nil {
+		return err
+	}
+	snapshot := Snapshot{
+		Database: DatabaseKey(projectRef, config),
+		TakenAt:  time.Now().UTC(),
+		Tables:   tables,
+	}
+	if err := Save(snapshot, fsys); err != nil {
+		return err
+	}
+	var total int64
+	for _, t := range tables {
+		total += t.TotalSize()
+	}
+	fmt.Fprintf(os.Stderr, "Saved snapshot of %d tables totalling %s to %s\n", len(tables), inspect.FormatSize(total), utils.Bold(utils.InspectHistoryPath))
+	return nil
+}
+
+func Query(ctx context.Context, conn *pgx.Conn) ([]Table, error) {
+	rows, err := conn.Query(ctx, QUERY)
+	if err != nil {
+		return nil, errors.Errorf("failed to query rows: %w", err)
+	}
+	result, err := pgxv5.CollectRows[Table](rows)
+	if err != nil {
+		return nil, err
+	}
+	bloated, err := bloat.Query(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	ratios := map[string]float64{}
+	for _, r := range bloated {
+		if ratio, err := strconv.ParseFloat(r.Bloat, 64); r.Type == "table" && err == nil {
+			ratios[r.Schemaname+"."+r.Object_name] = ratio
+		}
+	}
+	for i, t := range result {
+		result[i].Bloat = ratios[t.Schema+"."+t.Name]
+	}
+	return result, nil
+}
+
+// Save appends the snapshot to the history file as a single line of JSON.
+func Save(snapshot Snapshot, fsys afero.Fs) error {
+	if err := utils.MkdirIfNotExistFS(fsys, filepath.Dir(utils.InspectHistoryPath)); err != nil {
+		return err
+	}
+	f, err := fsys.OpenFile(utils.InspectHistoryPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(snapshot); err != nil {
+		return errors.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load returns snapshots of the database from the history file, in the order they were taken.
+func Load(database string, fsys afero.Fs) ([]Snapshot, error) {
+	f, err := fsys.Open(utils.InspectHistoryPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+	var result []Snapshot
+	scanner := bufio.NewScanner(f)
+	// Snapshots of large schemas may exceed the default line limit
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		var s Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, errors.Errorf("failed to parse history file: %w", err)
+		}
+		if s.Database == database {
+			result = append(result, s)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Errorf("failed to read history file: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,35 @@
+package snapshot
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/utils"
+)
+
+func TestDatabaseKey(t *testing.T) {
+	config := pgconn.Config{Host: "127.0.0.1", Port: 54322, Database: "postgres"}
+	assert.Equal(t, "127.0.0.1:54322/postgres", DatabaseKey("", config))
+	assert.Equal(t, "abcdefghijklmnopqrst", DatabaseKey("abcdefghijklmnopqrst", config))
+}
+
+func TestHistory(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	takenAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	local := Snapshot{Database: "127.0.0.1:54322/postgres", TakenAt: takenAt, Tables: []Table{{Schema: "public", Name: "users"}}}
+	linked := Snapshot{Database: "abcdefghijklmnopqrst", TakenAt: takenAt}
+	require.NoError(t, Save(local, fsys))
+	require.NoError(t, Save(linked, fsys))
+	// Check history is kept with the project, outside the temp dir removed by unlink
+	exists, err := afero.Exists(fsys, filepath.Join(utils.SupabaseDirPath, "inspect-history.jsonl"))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	history, err := Load(linked.Database, fsys)
+	assert.NoError(t, err)
+	assert.Equal(t, []Snapshot{linked}, history)
+}
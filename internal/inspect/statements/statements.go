@@ -0,0 +1,186 @@
+package statements
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/supabase/cli/internal/inspect"
+	"github.com/supabase/cli/internal/migration/list"
+	"github.com/supabase/cli/internal/utils"
+)
+
+// Nested and top level statements are counted together, like in the cumulative inspect queries.
+const SAMPLE_QUERY = `
+SELECT
+	concat_ws(':', userid, dbid, queryid) AS id,
+	min(query) AS query,
+	sum(calls)::int8 AS calls,
+	sum(total_exec_time) AS total_exec_time
+FROM pg_stat_statements
+GROUP BY userid, dbid, queryid
+`
+
+const (
+	OrderByCalls     = "calls"
+	OrderByTotalTime = "total_exec_time"
+
+	// Same number of rows as the cumulative inspect queries
+	Limit = 10
+)
+
+var DefaultWatchInterval = 10 * time.Second
+
+type counter struct {
+	query     string
+	calls     int64
+	totalTime float64
+}
+
+type Snapshot struct {
+	Time     time.Time
+	counters map[string]counter
+}
+
+type Delta struct {
+	Query           string  `json:"query" title:"Query"`
+	Calls           int64   `json:"calls" title:"Calls"`
+	Calls_per_sec   float64 `json:"calls_per_sec" title:"Calls/sec" format:"%.2f"`
+	Mean_exec_time  float64 `json:"mean_exec_time" title:"Mean time (ms)" format:"%.2f"`
+	Total_exec_time float64 `json:"total_exec_time" title:"Total time (ms)" format:"%.2f"`
+	Prop_exec_time  float64 `json:"prop_exec_time" title:"Proportion of exec time" format:"%.1f%%"`
+}
+
+func TakeSnapshot(ctx context.Context, conn *pgx.Conn) (Snapshot, error) {
+	result := Snapshot{counters: map[string]counter{}}
+	rows, err := conn.Query(ctx, SAMPLE_QUERY)
+	if err != nil {
+		return result, errors.Errorf("failed to query rows: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var c counter
+		if err := rows.Scan(&id, &c.query, &c.calls, &c.totalTime); err != nil {
+			return result, errors.Errorf("failed to scan rows: %w", err)
+		}
+		result.counters[id] = c
+	}
+	if err := rows.Err(); err != nil {
+		return result, errors.Errorf("failed to collect rows: %w", err)
+	}
+	result.Time = time.Now()
+	return result, nil
+}
+
+// Diff returns the top queries executed between two snapshots, sorted by orderBy in descending order.
+func Diff(before, after Snapshot, orderBy string) []Delta {
+	elapsed := after.Time.Sub(before.Time).Seconds()
+	var result []Delta
+	var sum float64
+	for id, c := range after.counters {
+		// Statements may be evicted or reset between snapshots
+		if prev, ok := before.counters[id]; ok && prev.calls <= c.calls {
+			c.calls -= prev.calls
+			c.totalTime -= prev.totalTime
+		}
+		if c.calls == 0 {
+			continue
+		}
+		sum += c.totalTime
+		d := Delta{
+			Query:           c.query,
+			Calls:           c.calls,
+			Mean_exec_time:  c.totalTime / float64(c.calls),
+			Total_exec_time: c.totalTime,
+		}
+		if elapsed > 0 {
+			d.Calls_per_sec = float64(c.calls) / elapsed
+		}
+		result = append(result, d)
+	}
+	for i := 0; sum > 0 && i < len(result); i++ {
+		result[i].Prop_exec_time = result[i].Total_exec_time / sum * 100
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if orderBy == OrderByCalls && result[i].Calls != result[j].Calls {
+			return result[i].Calls > result[j].Calls
+		}
+		return result[i].Total_exec_time > result[j].Total_exec_time
+	})
+	if len(result) > Limit {
+		result = result[:Limit]
+	}
+	return result
+}
+
+// Run samples pg_stat_statements twice, interval apart, and reports the queries executed in between.
+func Run(ctx context.Context, output, orderBy string, interval time.Duration, config pgconn.Config, options ...func(*pgx.ConnConfig)) error {
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	before, err := TakeSnapshot(ctx, conn)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Sampling query statistics for %s...\n", interval)
+	select {
+	case <-ctx.Done():
+		return errors.New(ctx.Err())
+	case <-time.After(interval):
+	}
+	after, err := TakeSnapshot(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return inspect.Render(output, Diff(before, after, orderBy))
+}
+
+// Watch refreshes the query statistics sampled over each interval until cancelled.
+func Watch(ctx context.Context, orderBy string, interval time.Duration, config pgconn.Config, options ...func(*pgx.ConnConfig)) error {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	return utils.RunProgram(ctx, func(p utils.Program, ctx context.Context) error {
+		p.Send(utils.StatusMsg(fmt.Sprintf("Sampling query statistics for %s...", interval)))
+		before, err := TakeSnapshot(ctx, conn)
+		if err != nil {
+			return err
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+			after, err := TakeSnapshot(ctx, conn)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+			table, err := list.RenderMarkdown(inspect.MarkdownTable(Diff(before, after, orderBy)))
+			if err != nil {
+				return err
+			}
+			p.Send(utils.TableMsg(table))
+			p.Send(utils.StatusMsg(fmt.Sprintf("Updated at %s, refreshing every %s. Press Ctrl+C to exit.", after.Time.Format(time.TimeOnly), interval)))
+			before = after
+		}
+	})
+}
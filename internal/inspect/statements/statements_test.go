@@ -0,0 +1,92 @@
+package statements
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("subtracts counters between snapshots", func(t *testing.T) {
+		before := Snapshot{Time: start, counters: map[string]counter{
+			"1": {query: "select 1", calls: 10, totalTime: 100},
+			"2": {query: "select 2", calls: 5, totalTime: 50},
+		}}
+		after := Snapshot{Time: start.Add(10 * time.Second), counters: map[string]counter{
+			"1": {query: "select 1", calls: 30, totalTime: 400},
+			"2": {query: "select 2", calls: 5, totalTime: 50},
+			"3": {query: "select 3", calls: 10, totalTime: 100},
+		}}
+		// Run test
+		result := Diff(before, after, OrderByTotalTime)
+		// Check result
+		assert.Equal(t, []Delta{{
+			Query:           "select 1",
+			Calls:           20,
+			Calls_per_sec:   2,
+			Mean_exec_time:  15,
+			Total_exec_time: 300,
+			Prop_exec_time:  75,
+		}, {
+			Query:           "select 3",
+			Calls:           10,
+			Calls_per_sec:   1,
+			Mean_exec_time:  10,
+			Total_exec_time: 100,
+			Prop_exec_time:  25,
+		}}, result)
+	})
+
+	t.Run("uses current counters after reset", func(t *testing.T) {
+		before := Snapshot{Time: start, counters: map[string]counter{
+			"1": {query: "select 1", calls: 100, totalTime: 1000},
+		}}
+		after := Snapshot{Time: start.Add(time.Second), counters: map[string]counter{
+			"1": {query: "select 1", calls: 4, totalTime: 8},
+		}}
+		// Run test
+		result := Diff(before, after, OrderByCalls)
+		// Check result
+		require.Len(t, result, 1)
+		assert.Equal(t, int64(4), result[0].Calls)
+		assert.Equal(t, float64(8), result[0].Total_exec_time)
+		assert.Equal(t, float64(100), result[0].Prop_exec_time)
+	})
+
+	t.Run("ignores evicted statements", func(t *testing.T) {
+		before := Snapshot{Time: start, counters: map[string]counter{
+			"1": {query: "select 1", calls: 10, totalTime: 10},
+		}}
+		after := Snapshot{Time: start.Add(time.Second), counters: map[string]counter{}}
+		// Run test
+		result := Diff(before, after, OrderByCalls)
+		// Check result
+		assert.Empty(t, result)
+	})
+
+	t.Run("keeps top queries with proportion of all queries", func(t *testing.T) {
+		before := Snapshot{Time: start, counters: map[string]counter{}}
+		after := Snapshot{Time: start.Add(time.Second), counters: map[string]counter{}}
+		for i := 1; i <= 20; i++ {
+			after.counters[fmt.Sprint(i)] = counter{
+				query:     fmt.Sprintf("select %d", i),
+				calls:     int64(21 - i),
+				totalTime: 10,
+			}
+		}
+		// Run test
+		result := Diff(before, after, OrderByCalls)
+		// Check result
+		require.Len(t, result, Limit)
+		for i, d := range result {
+			assert.Equal(t, fmt.Sprintf("select %d", i+1), d.Query)
+			// Each query takes 10ms of 200ms in total
+			assert.Equal(t, float64(5), d.Prop_exec_time)
+		}
+	})
+}
@@ -2,14 +2,13 @@ package vacuum_stats
 
 import (
 	"context"
-	"fmt"
 	"strings"
 
 	"github.com/go-errors/errors"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/spf13/afero"
-	"github.com/supabase/cli/internal/migration/list"
+	"github.com/supabase/cli/internal/inspect"
 	"github.com/supabase/cli/internal/utils"
 	"github.com/supabase/cli/internal/utils/pgxv5"
 )
@@ -61,34 +60,38 @@ ORDER BY
   1`
 
 type Result struct {
-	Schema               string
-	Table                string
-	Last_vacuum          string
-	Last_autovacuum      string
-	Rowcount             string
-	Dead_rowcount        string
-	Autovacuum_threshold string
-	Expect_autovacuum    string
+	Schema               string `json:"schema" title:"Schema"`
+	Table                string `json:"table" title:"Table"`
+	Last_vacuum          string `json:"last_vacuum" title:"Last Vacuum"`
+	Last_autovacuum      string `json:"last_autovacuum" title:"Last Auto Vacuum"`
+	Rowcount             string `json:"rowcount" title:"Row count"`
+	Dead_rowcount        string `json:"dead_rowcount" title:"Dead row count"`
+	Autovacuum_threshold string `json:"autovacuum_threshold" title:"-"`
+	Expect_autovacuum    string `json:"expect_autovacuum" title:"Expect autovacuum?"`
 }
 
-func Run(ctx context.Context, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+func Run(ctx context.Context, output string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
 	conn, err := utils.ConnectByConfig(ctx, config, options...)
 	if err != nil {
 		return err
 	}
-	rows, err := conn.Query(ctx, QUERY)
-	if err != nil {
-		return errors.Errorf("failed to query rows: %w", err)
-	}
-	result, err := pgxv5.CollectRows[Result](rows)
+	result, err := Query(ctx, conn)
 	if err != nil {
 		return err
 	}
 
-	table := "|Schema|Table|Last Vacuum|Last Auto Vacuum|Row count|Dead row count|Expect autovacuum?\n|-|-|-|-|-|-|-|\n"
-	for _, r := range result {
-		rowcount := strings.Replace(r.Rowcount, "-1", "No stats", 1)
-		table += fmt.Sprintf("|`%s`|`%s`|%s|%s|`%s`|`%s`|`%s`|\n", r.Schema, r.Table, r.Last_vacuum, r.Last_autovacuum, rowcount, r.Dead_rowcount, r.Expect_autovacuum)
+	if output == utils.OutputPretty {
+		for i := range result {
+			result[i].Rowcount = strings.Replace(result[i].Rowcount, "-1", "No stats", 1)
+		}
+	}
+	return inspect.Render(output, result)
+}
+
+func Query(ctx context.Context, conn *pgx.Conn) ([]Result, error) {
+	rows, err := conn.Query(ctx, QUERY)
+	if err != nil {
+		return nil, errors.Errorf("failed to query rows: %w", err)
 	}
-	return list.RenderTable(table)
+	return pgxv5.CollectRows[Result](rows)
 }
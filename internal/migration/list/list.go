@@ -117,19 +117,27 @@ func makeTable(remoteMigrations, localMigrations []string) string {
 }
 
 func RenderTable(markdown string) error {
+	out, err := RenderMarkdown(markdown)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+func RenderMarkdown(markdown string) (string, error) {
 	r, err := glamour.NewTermRenderer(
 		glamour.WithAutoStyle(),
 		glamour.WithWordWrap(-1),
 	)
 	if err != nil {
-		return errors.Errorf("failed to initialise terminal renderer: %w", err)
+		return "", errors.Errorf("failed to initialise terminal renderer: %w", err)
 	}
 	out, err := r.Render(markdown)
 	if err != nil {
-		return errors.Errorf("failed to render markdown: %w", err)
+		return "", errors.Errorf("failed to render markdown: %w", err)
 	}
-	fmt.Print(out)
-	return nil
+	return out, nil
 }
 
 func LoadLocalVersions(fsys afero.Fs) ([]string, error) {
@@ -190,3 +190,13 @@ func TestMakeTable(t *testing.T) {
 		}, lines)
 	})
 }
+
+func TestRenderMarkdown(t *testing.T) {
+	// Run test
+	out, err := RenderMarkdown("|Query|Calls|\n|-|-|\n|`select 1`|`10`|\n")
+	// Check error
+	assert.NoError(t, err)
+	assert.Contains(t, out, "QUERY")
+	assert.Contains(t, out, "select 1")
+	assert.Contains(t, out, "10")
+}
@@ -0,0 +1,180 @@
+package generate
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/go-errors/errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/supabase/cli/internal/db/reset"
+	"github.com/supabase/cli/internal/test/new"
+	"github.com/supabase/cli/internal/utils"
+)
+
+const (
+	// Tables and functions owned by extensions are not part of user schema
+	LIST_TABLES = `select c.relname::text,
+  coalesce((select json_agg(json_build_object('name', a.attname, 'type', pg_catalog.format_type(a.atttypid, a.atttypmod)) order by a.attnum)
+    from pg_catalog.pg_attribute a where a.attrelid = c.oid and a.attnum > 0 and not a.attisdropped), '[]'),
+  coalesce((select json_agg(i.relname order by i.relname)
+    from pg_catalog.pg_index x join pg_catalog.pg_class i on i.oid = x.indexrelid where x.indrelid = c.oid), '[]'),
+  coalesce((select json_agg(p.polname order by p.polname)
+    from pg_catalog.pg_policy p where p.polrelid = c.oid), '[]')
+from pg_catalog.pg_class c
+join pg_catalog.pg_namespace n on n.oid = c.relnamespace
+where n.nspname = $1 and c.relkind in ('r', 'p') and not c.relispartition
+  and not exists (select 1 from pg_catalog.pg_depend d where d.classid = 'pg_catalog.pg_class'::regclass and d.objid = c.oid and d.deptype = 'e')
+order by 1`
+	LIST_FUNCTIONS = `select p.proname::text,
+  coalesce((select json_agg(pg_catalog.format_type(a.t, null) order by a.o)
+    from unnest(p.proargtypes) with ordinality a(t, o)), '[]'),
+  case when p.proretset then 'setof ' else '' end || p.prorettype::regtype::text
+from pg_catalog.pg_proc p
+join pg_catalog.pg_namespace n on n.oid = p.pronamespace
+where n.nspname = $1 and p.prokind = 'f'
+  and not exists (select 1 from pg_catalog.pg_depend d where d.classid = 'pg_catalog.pg_proc'::regclass and d.objid = p.oid and d.deptype = 'e')
+order by 1, pg_catalog.pg_get_function_identity_arguments(p.oid)`
+)
+
+var (
+	//go:embed templates/schema.sql
+	schemaTemplate string
+
+	testTemplate = template.Must(template.New("schema").Funcs(template.FuncMap{
+		"quote": quoteLiteral,
+		"array": quoteArray,
+	}).Parse(schemaTemplate))
+)
+
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type Table struct {
+	Name     string
+	Columns  []Column
+	Indexes  []string
+	Policies []string
+}
+
+type Function struct {
+	Name    string
+	Args    []string
+	Returns string
+}
+
+type SchemaTest struct {
+	Schema    string
+	Tables    []Table
+	Functions []Function
+}
+
+// Number of assertions generated by the template.
+func (s SchemaTest) Plan() int {
+	count := len(s.Functions)
+	for _, t := range s.Tables {
+		// has_table, indexes_are, and policies_are
+		count += 3 + len(t.Columns)
+	}
+	return count
+}
+
+func Run(ctx context.Context, schema []string, config pgconn.Config, fsys afero.Fs, options ...func(*pgx.ConnConfig)) error {
+	conn, err := utils.ConnectByConfig(ctx, config, options...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	if len(schema) == 0 {
+		if schema, err = reset.ListSchemas(ctx, conn, utils.InternalSchemas...); err != nil {
+			return err
+		}
+	}
+	for _, s := range schema {
+		fmt.Fprintln(os.Stderr, "Generating tests for schema:", s)
+		test, err := introspectSchema(ctx, s, conn)
+		if err != nil {
+			return err
+		}
+		if len(test.Tables) == 0 && len(test.Functions) == 0 {
+			fmt.Fprintln(os.Stderr, "Skipping empty schema:", s)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := testTemplate.Execute(&buf, test); err != nil {
+			return errors.Errorf("failed to generate test: %w", err)
+		}
+		path, err := new.WriteTest(s+"_schema", buf.Bytes(), fsys)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Created schema test at " + utils.Bold(path) + ".")
+	}
+	return nil
+}
+
+func introspectSchema(ctx context.Context, schema string, conn *pgx.Conn) (SchemaTest, error) {
+	result := SchemaTest{Schema: schema}
+	rows, err := conn.Query(ctx, LIST_TABLES, schema)
+	if err != nil {
+		return result, errors.Errorf("failed to list tables: %w", err)
+	}
+	for rows.Next() {
+		var t Table
+		var columns, indexes, policies []byte
+		if err := rows.Scan(&t.Name, &columns, &indexes, &policies); err != nil {
+			return result, errors.Errorf("failed to scan tables: %w", err)
+		}
+		for _, v := range []struct {
+			data []byte
+			dest any
+		}{{columns, &t.Columns}, {indexes, &t.Indexes}, {policies, &t.Policies}} {
+			if err := json.Unmarshal(v.data, v.dest); err != nil {
+				return result, errors.Errorf("failed to parse table: %w", err)
+			}
+		}
+		result.Tables = append(result.Tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return result, errors.Errorf("failed to parse tables: %w", err)
+	}
+	if rows, err = conn.Query(ctx, LIST_FUNCTIONS, schema); err != nil {
+		return result, errors.Errorf("failed to list functions: %w", err)
+	}
+	for rows.Next() {
+		var f Function
+		var args []byte
+		if err := rows.Scan(&f.Name, &args, &f.Returns); err != nil {
+			return result, errors.Errorf("failed to scan functions: %w", err)
+		}
+		if err := json.Unmarshal(args, &f.Args); err != nil {
+			return result, errors.Errorf("failed to parse function: %w", err)
+		}
+		result.Functions = append(result.Functions, f)
+	}
+	if err := rows.Err(); err != nil {
+		return result, errors.Errorf("failed to parse functions: %w", err)
+	}
+	return result, nil
+}
+
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func quoteArray(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = quoteLiteral(v)
+	}
+	return "ARRAY[" + strings.Join(quoted, ", ") + "]::name[]"
+}
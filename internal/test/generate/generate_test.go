@@ -0,0 +1,110 @@
+package generate
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/supabase/cli/internal/testing/pgtest"
+	"github.com/supabase/cli/internal/utils"
+)
+
+var dbConfig = pgconn.Config{
+	Host:     "127.0.0.1",
+	Port:     5432,
+	User:     "admin",
+	Password: "password",
+	Database: "postgres",
+}
+
+func TestGenerateCommand(t *testing.T) {
+	t.Run("generates schema test", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(LIST_TABLES, "public").
+			Reply("SELECT 1", []interface{}{
+				"profiles",
+				`[{"name":"id","type":"uuid"},{"name":"username","type":"character varying(255)"}]`,
+				`["profiles_pkey"]`,
+				`["Users can view own profile"]`,
+			}).
+			Query(LIST_FUNCTIONS, "public").
+			Reply("SELECT 3",
+				[]interface{}{"handle_new_user", `[]`, "trigger"},
+				// Returns setof profiles
+				[]interface{}{"list_profiles", `[]`, "setof profiles"},
+				// Returns table(id uuid, rank real)
+				[]interface{}{"search_profiles", `["text"]`, "setof record"},
+			).
+			Query(LIST_TABLES, "empty").
+			Reply("SELECT 0").
+			Query(LIST_FUNCTIONS, "empty").
+			Reply("SELECT 0")
+		// Run test
+		err := Run(context.Background(), []string{"public", "empty"}, dbConfig, fsys, conn.Intercept)
+		// Check error
+		assert.NoError(t, err)
+		contents, err := afero.ReadFile(fsys, filepath.Join(utils.DbTestsDir, "public_schema_test.sql"))
+		assert.NoError(t, err)
+		assert.Equal(t, `BEGIN;
+SELECT plan(8);
+
+-- Generated from schema public, update the assertions when making intentional changes
+
+SELECT has_table('public', 'profiles');
+SELECT col_type_is('public', 'profiles', 'id', 'uuid');
+SELECT col_type_is('public', 'profiles', 'username', 'character varying(255)');
+SELECT indexes_are('public', 'profiles', ARRAY['profiles_pkey']::name[]);
+SELECT policies_are('public', 'profiles', ARRAY['Users can view own profile']::name[]);
+
+SELECT function_returns('public', 'handle_new_user', ARRAY[]::name[], 'trigger');
+SELECT function_returns('public', 'list_profiles', ARRAY[]::name[], 'setof profiles');
+SELECT function_returns('public', 'search_profiles', ARRAY['text']::name[], 'setof record');
+
+SELECT * FROM finish();
+ROLLBACK;
+`, string(contents))
+		exists, err := afero.Exists(fsys, filepath.Join(utils.DbTestsDir, "empty_schema_test.sql"))
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("throws error on existing test", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fsys, filepath.Join(utils.DbTestsDir, "public_schema_test.sql"), []byte{}, 0644))
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(LIST_TABLES, "public").
+			Reply("SELECT 0").
+			Query(LIST_FUNCTIONS, "public").
+			Reply("SELECT 1", []interface{}{"add", `["integer","integer"]`, "integer"})
+		// Run test
+		err := Run(context.Background(), []string{"public"}, dbConfig, fsys, conn.Intercept)
+		// Check error
+		assert.ErrorContains(t, err, "already exists")
+	})
+
+	t.Run("throws error on query failure", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		// Setup mock postgres
+		conn := pgtest.NewConn()
+		defer conn.Close(t)
+		conn.Query(LIST_TABLES, "public").
+			ReplyError(pgerrcode.InsufficientPrivilege, "permission denied for table pg_class")
+		// Run test
+		err := Run(context.Background(), []string{"public"}, dbConfig, fsys, conn.Intercept)
+		// Check error
+		assert.ErrorContains(t, err, "permission denied for table pg_class")
+	})
+}
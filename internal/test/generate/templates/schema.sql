@@ -0,0 +1,21 @@
+BEGIN;
+SELECT plan({{ .Plan }});
+
+-- Generated from schema {{ .Schema }}, update the assertions when making intentional changes
+{{- range $table := .Tables }}
+
+SELECT has_table({{ quote $.Schema }}, {{ quote $table.Name }});
+{{- range $table.Columns }}
+SELECT col_type_is({{ quote $.Schema }}, {{ quote $table.Name }}, {{ quote .Name }}, {{ quote .Type }});
+{{- end }}
+SELECT indexes_are({{ quote $.Schema }}, {{ quote $table.Name }}, {{ array $table.Indexes }});
+SELECT policies_are({{ quote $.Schema }}, {{ quote $table.Name }}, {{ array $table.Policies }});
+{{- end }}
+{{- if .Functions }}
+{{ range .Functions }}
+SELECT function_returns({{ quote $.Schema }}, {{ quote .Name }}, {{ array .Args }}, {{ quote .Returns }});
+{{- end }}
+{{- end }}
+
+SELECT * FROM finish();
+ROLLBACK;
@@ -13,29 +13,43 @@ import (
 
 const (
 	TemplatePgTAP = "pgtap"
+	TemplateRLS   = "rls"
 )
 
 var (
 	//go:embed templates/pgtap.sql
 	pgtapTest []byte
+	//go:embed templates/rls.sql
+	rlsTest []byte
 )
 
 func Run(ctx context.Context, name, template string, fsys afero.Fs) error {
-	path := filepath.Join(utils.DbTestsDir, fmt.Sprintf("%s_test.sql", name))
-	if _, err := fsys.Stat(path); err == nil {
-		return errors.New(path + " already exists.")
-	}
-	if err := utils.WriteFile(path, getTemplate(template), fsys); err != nil {
+	path, err := WriteTest(name, getTemplate(template), fsys)
+	if err != nil {
 		return err
 	}
 	fmt.Printf("Created new %s test at %s.\n", template, utils.Bold(path))
 	return nil
 }
 
+// WriteTest creates a test file with the given name, without overwriting existing tests.
+func WriteTest(name string, contents []byte, fsys afero.Fs) (string, error) {
+	path := filepath.Join(utils.DbTestsDir, fmt.Sprintf("%s_test.sql", name))
+	if _, err := fsys.Stat(path); err == nil {
+		return path, errors.New(path + " already exists.")
+	}
+	if err := utils.WriteFile(path, contents, fsys); err != nil {
+		return path, err
+	}
+	return path, nil
+}
+
 func getTemplate(name string) []byte {
 	switch name {
 	case TemplatePgTAP:
 		return pgtapTest
+	case TemplateRLS:
+		return rlsTest
 	}
 	return nil
 }
@@ -44,3 +44,17 @@ func TestCreatePgTAP(t *testing.T) {
 		assert.ErrorContains(t, err, "already exists")
 	})
 }
+
+func TestCreateRLS(t *testing.T) {
+	t.Run("creates test file", func(t *testing.T) {
+		// Setup in-memory fs
+		fsys := afero.NewMemMapFs()
+		// Run test
+		err := Run(context.Background(), "profiles", TemplateRLS, fsys)
+		// Check error
+		assert.NoError(t, err)
+		contents, err := afero.ReadFile(fsys, filepath.Join(utils.DbTestsDir, "profiles_test.sql"))
+		assert.NoError(t, err)
+		assert.Equal(t, rlsTest, contents)
+	})
+}
@@ -0,0 +1,19 @@
+BEGIN;
+SELECT plan(3);
+
+-- Helpers are installed by `supabase test db` in the tests schema
+SELECT tests.rls_enabled('public');
+
+SELECT tests.create_user('alice@example.com');
+SELECT tests.create_user('bob@example.com');
+
+SELECT tests.authenticate_as('alice@example.com');
+-- Replace with a query that should only return rows owned by alice
+SELECT ok(true, 'alice can only see her own rows');
+
+SELECT tests.clear_authentication();
+-- Replace with a query that anonymous users should not be able to see
+SELECT ok(true, 'anonymous users cannot see any rows');
+
+SELECT * FROM finish();
+ROLLBACK;
@@ -39,7 +39,6 @@ const (
 	EdgeRuntimeImage = "supabase/edge-runtime:v1.41.3"
 	VectorImage      = "timberio/vector:0.28.1-alpine"
 	PgbouncerImage   = "bitnami/pgbouncer:1.20.1-debian-11-r39"
-	PgProveImage     = "supabase/pg_prove:3.36"
 	GotrueImage      = "supabase/gotrue:v2.145.0"
 	RealtimeImage    = "supabase/realtime:v2.27.5"
 	StorageImage     = "supabase/storage-api:v0.46.4"
@@ -64,7 +63,6 @@ var ServiceImages = []string{
 	LogflareImage,
 	VectorImage,
 	PgbouncerImage,
-	PgProveImage,
 }
 
 func ShortContainerImageName(imageName string) string {
@@ -185,6 +183,10 @@ var (
 	DbTestsDir            = filepath.Join(SupabaseDirPath, "tests")
 	SeedDataPath          = filepath.Join(SupabaseDirPath, "seed.sql")
 	CustomRolesPath       = filepath.Join(SupabaseDirPath, "roles.sql")
+	MaskingRulesPath      = filepath.Join(SupabaseDirPath, "masking.toml")
+	InspectRulesPath      = filepath.Join(SupabaseDirPath, "inspect.toml")
+	InspectQueriesDir     = filepath.Join(SupabaseDirPath, "inspect")
+	InspectHistoryPath    = filepath.Join(SupabaseDirPath, "inspect-history.jsonl")
 
 	ErrNotLinked   = errors.Errorf("Cannot find project ref. Have you run %s?", Aqua("supabase link"))
 	ErrInvalidRef  = errors.New("Invalid project ref format. Must be like `abcdefghijklmnopqrst`.")
@@ -245,6 +247,16 @@ func getProjectRoot(absPath string, fsys afero.Fs) string {
 	return absPath
 }
 
+// GetProjectRoot returns the project directory containing the current directory, before
+// the workdir is changed when a command runs.
+func GetProjectRoot(fsys afero.Fs) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", errors.Errorf("failed to get current directory: %w", err)
+	}
+	return getProjectRoot(cwd, fsys), nil
+}
+
 func isRootDirectory(cleanPath string) bool {
 	// A cleaned path only ends with separator if it is root
 	return os.IsPathSeparator(cleanPath[len(cleanPath)-1])
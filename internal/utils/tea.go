@@ -62,6 +62,8 @@ func (p *fakeProgram) Send(msg tea.Msg) {
 		if msg != nil {
 			fmt.Println(*msg)
 		}
+	case TableMsg:
+		fmt.Print(msg)
 	}
 
 	_, cmd := p.model.Update(msg)
@@ -78,6 +80,7 @@ type (
 	StatusMsg   string
 	ProgressMsg *float64
 	PsqlMsg     *string
+	TableMsg    string
 )
 
 type StatusWriter struct {
@@ -119,6 +122,7 @@ type logModel struct {
 	status      string
 	progress    *progress.Model
 	psqlOutputs []string
+	table       string
 
 	width int
 }
@@ -181,6 +185,9 @@ func (m logModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.psqlOutputs = m.psqlOutputs[1:]
 		}
 		return m, nil
+	case TableMsg:
+		m.table = string(msg)
+		return m, nil
 	default:
 		return m, nil
 	}
@@ -197,5 +204,11 @@ func (m logModel) View() string {
 		psqlOutputs = "\n\n" + strings.Join(m.psqlOutputs, "\n")
 	}
 
-	return wrap.String(m.spinner.View()+m.status+progress+psqlOutputs, m.width)
+	var table string
+	if len(m.table) > 0 {
+		// Tables are rendered separately to avoid wrapping long rows
+		table = "\n" + m.table
+	}
+
+	return wrap.String(m.spinner.View()+m.status+progress+psqlOutputs, m.width) + table
 }
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableMsg(t *testing.T) {
+	row := "| " + strings.Repeat("select * from a_very_long_table_name ", 4) + "|"
+
+	t.Run("renders table below status without wrapping", func(t *testing.T) {
+		var model tea.Model = logModel{}
+		model, _ = model.Update(tea.WindowSizeMsg{Width: 40})
+		model, _ = model.Update(StatusMsg("Sampling query statistics"))
+		// Run test
+		model, _ = model.Update(TableMsg(row + "\n"))
+		// Check view
+		view := model.View()
+		assert.Contains(t, view, "Sampling query statistics\n"+row+"\n")
+	})
+
+	t.Run("replaces previous table", func(t *testing.T) {
+		var model tea.Model = logModel{}
+		model, _ = model.Update(TableMsg("old"))
+		// Run test
+		model, _ = model.Update(TableMsg("new"))
+		// Check view
+		view := model.View()
+		assert.NotContains(t, view, "old")
+		assert.Contains(t, view, "\nnew")
+	})
+}
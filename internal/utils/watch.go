@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-errors/errors"
+)
+
+// Editors tend to save files in bursts of events, which are coalesced into a single change.
+var WatchDebounce = 500 * time.Millisecond
+
+// WatchFiles calls onChange with the list of changed files under paths, until the context is cancelled.
+func WatchFiles(ctx context.Context, paths []string, onChange func(changed []string) error) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+	for _, p := range paths {
+		// Files are watched via their parent directory to handle editors that replace on save
+		dir := p
+		if info, err := os.Stat(p); err != nil || !info.IsDir() {
+			dir = filepath.Dir(p)
+		}
+		if err := watchDir(watcher, dir); err != nil {
+			return err
+		}
+	}
+	events := make(chan string)
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						if err := watchDir(watcher, event.Name); err != nil {
+							fmt.Fprintln(os.Stderr, err)
+						}
+					}
+				}
+				if event.Op == fsnotify.Chmod || !matchesAny(event.Name, paths) {
+					continue
+				}
+				select {
+				case events <- event.Name:
+				case <-ctx.Done():
+					return
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fmt.Fprintln(os.Stderr, "failed to watch files:", err)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return debounceEvents(ctx, events, WatchDebounce, onChange)
+}
+
+func watchDir(watcher *fsnotify.Watcher, dir string) error {
+	if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	}); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Errorf("failed to watch directory: %w", err)
+	}
+	return nil
+}
+
+func matchesAny(name string, paths []string) bool {
+	name = filepath.Clean(name)
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		if name == p || strings.HasPrefix(name, p+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func debounceEvents(ctx context.Context, events <-chan string, interval time.Duration, onChange func(changed []string) error) error {
+	changed := map[string]struct{}{}
+	timer := time.NewTimer(interval)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case name := <-events:
+			changed[name] = struct{}{}
+			timer.Reset(interval)
+		case <-timer.C:
+			names := make([]string, 0, len(changed))
+			for name := range changed {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			clear(changed)
+			if err := onChange(names); err != nil {
+				return err
+			}
+		}
+	}
+}
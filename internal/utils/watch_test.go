@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-errors/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebounceEvents(t *testing.T) {
+	t.Run("coalesces burst of events", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		events := make(chan string)
+		go func() {
+			for _, name := range []string{"b.sql", "a.sql", "b.sql"} {
+				events <- name
+			}
+		}()
+		var calls [][]string
+		// Run test
+		err := debounceEvents(ctx, events, 10*time.Millisecond, func(changed []string) error {
+			calls = append(calls, changed)
+			cancel()
+			return nil
+		})
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{{"a.sql", "b.sql"}}, calls)
+	})
+
+	t.Run("throws error on change failure", func(t *testing.T) {
+		errChange := errors.New("change failed")
+		events := make(chan string, 1)
+		events <- "a.sql"
+		// Run test
+		err := debounceEvents(context.Background(), events, time.Millisecond, func(changed []string) error {
+			return errChange
+		})
+		// Check error
+		assert.ErrorIs(t, err, errChange)
+	})
+}
+
+func TestWatchFiles(t *testing.T) {
+	t.Run("watches nested files", func(t *testing.T) {
+		dir := t.TempDir()
+		nested := filepath.Join(dir, "tests", "nested")
+		require.NoError(t, os.MkdirAll(nested, 0755))
+		seed := filepath.Join(dir, "seed.sql")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		var changed []string
+		go func() {
+			// Wait for watcher to start
+			time.Sleep(100 * time.Millisecond)
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.sql"), []byte{}, 0644))
+			assert.NoError(t, os.WriteFile(filepath.Join(nested, "a_test.sql"), []byte{}, 0644))
+			assert.NoError(t, os.WriteFile(seed, []byte{}, 0644))
+		}()
+		// Run test
+		err := WatchFiles(ctx, []string{filepath.Join(dir, "tests"), seed}, func(names []string) error {
+			changed = names
+			cancel()
+			return nil
+		})
+		// Check error
+		assert.NoError(t, err)
+		assert.Equal(t, []string{seed, filepath.Join(nested, "a_test.sql")}, changed)
+	})
+}